@@ -1,8 +1,9 @@
 package errors
 
 import (
+	"context"
 	"net/http"
-	"runtime"
+	"path"
 	"strings"
 	"time"
 )
@@ -35,9 +36,36 @@ func (s *cloudErrorBuilder) Message(errMsg string) *cloudErrorBuilder {
 	return s
 }
 
+// Safe marks the message of the error as safe to send to clients, so that the
+// handlers send it even for a server error in production mode.
+func (s *cloudErrorBuilder) Safe() *cloudErrorBuilder {
+	s.err.safe = true
+	return s
+}
+
+// InternalMessage sets the text of the error meant for developers rather than
+// clients.
+func (s *cloudErrorBuilder) InternalMessage(msg string) *cloudErrorBuilder {
+	s.err.InternalMessage = msg
+	return s
+}
+
+// MessageKey sets the key of the message in a Catalog, and the args its
+// template is filled in with, so that the handlers can translate it.
+func (s *cloudErrorBuilder) MessageKey(key string, args map[string]any) *cloudErrorBuilder {
+	s.err.MessageKey = key
+	s.err.MessageArgs = args
+	return s
+}
+
+// ErrorLocation sets the service, package and function of the error's
+// location. Any empty value is filled in by Build from the runtime caller or,
+// for the service, from ServiceName.
 func (s *cloudErrorBuilder) ErrorLocation(svc, pkg, fnc string) *cloudErrorBuilder {
 	s.err.ErrorLocation.Service = svc
+	s.err.ErrorLocation.Package = pkg
 	s.err.ErrorLocation.Method = fnc
+	s.err.ErrorLocation.Function = strings.TrimPrefix(fnc, pkg+".")
 	return s
 }
 
@@ -51,11 +79,15 @@ func (s *cloudErrorBuilder) CorrelationID(id string) *cloudErrorBuilder {
 	return s
 }
 
+// Error sets the cause of the error, or its message if err is a string. The
+// text of a cause is kept as the InternalMessage rather than the message, as
+// it is rarely fit for clients; without a message the error gets the status
+// text.
 func (s *cloudErrorBuilder) Error(err any) *cloudErrorBuilder {
 	switch e := err.(type) {
 	case error:
 		s.err.InternalError = e
-		s.err.Message = e.Error()
+		s.err.InternalMessage = e.Error()
 	case string:
 		s.err.Message = e
 	}
@@ -73,6 +105,33 @@ func (s *cloudErrorBuilder) Tags(tags ...string) *cloudErrorBuilder {
 	return s
 }
 
+// Details sets structured details of the error, e.g. the messages of a
+// validator, that are sent to clients alongside the message.
+func (s *cloudErrorBuilder) Details(details any) *cloudErrorBuilder {
+	s.err.Details = details
+	return s
+}
+
+// Context fills the correlation ID, service, user ID, tenant ID and tags of
+// the error from ctx. Values already set on the builder are kept, and the tags
+// in ctx are added to any already set.
+func (s *cloudErrorBuilder) Context(ctx context.Context) *cloudErrorBuilder {
+	if s.err.CorrelationID == "" {
+		s.err.CorrelationID = CorrelationIDFromContext(ctx)
+	}
+	if s.err.ErrorLocation.Service == "" {
+		s.err.ErrorLocation.Service = ServiceFromContext(ctx)
+	}
+	if s.err.UserID == "" {
+		s.err.UserID = UserIDFromContext(ctx)
+	}
+	if s.err.TenantID == "" {
+		s.err.TenantID = TenantIDFromContext(ctx)
+	}
+	s.err.Tags = append(s.err.Tags, TagsFromContext(ctx)...)
+	return s
+}
+
 // SkipCaller allows you to skip levels of the trace when trying to determine in which
 // method the errors was called.
 func (s *cloudErrorBuilder) SkipCaller(skip int) *cloudErrorBuilder {
@@ -80,7 +139,27 @@ func (s *cloudErrorBuilder) SkipCaller(skip int) *cloudErrorBuilder {
 	return s
 }
 
+// CaptureStack records the full call stack of the error when it is built.
+func (s *cloudErrorBuilder) CaptureStack() *cloudErrorBuilder {
+	s.err.Stack = &Stack{}
+	return s
+}
+
 func (s *cloudErrorBuilder) Build(t time.Time, options ...CloudErrorOption) *CloudError {
+	// registered codes provide the defaults for anything that was not set
+	if def, ok := LookupCode(s.err.CustomCode); ok {
+		if s.err.StatusCode == 0 {
+			s.err.StatusCode = def.StatusCode
+			s.err.Status = http.StatusText(def.StatusCode)
+		}
+		if s.err.Message == "" {
+			s.err.Message = def.Message
+		}
+	}
+	if s.err.StatusCode == 0 && len(s.err.FieldErrors) > 0 {
+		s.err.StatusCode = http.StatusBadRequest
+		s.err.Status = http.StatusText(http.StatusBadRequest)
+	}
 	if s.err.StatusCode == 0 {
 		s.err.StatusCode = 500
 		s.err.Status = http.StatusText(500)
@@ -95,13 +174,12 @@ func (s *cloudErrorBuilder) Build(t time.Time, options ...CloudErrorOption) *Clo
 		s.err.Source = "music-tribe"
 	}
 
-	pc, page, line, _ := runtime.Caller(s.err.ErrorLocation.skip)
-	funcDetails := runtime.FuncForPC(pc)
-
-	name := funcDetails.Name()
-	s.err.ErrorLocation.Page = page
-	s.err.ErrorLocation.Line = line
-	s.err.ErrorLocation.Method = name
+	// values given to the builder take precedence over the runtime caller
+	given := s.err.ErrorLocation
+	s.err.ErrorLocation = callerLocation(given.skip).withGiven(given)
+	if s.err.ErrorLocation.Service == "" {
+		s.err.ErrorLocation.Service = ServiceName()
+	}
 
 	s.err.TimeStamp = t
 
@@ -109,15 +187,20 @@ func (s *cloudErrorBuilder) Build(t time.Time, options ...CloudErrorOption) *Clo
 		option(s.err)
 	}
 
-	// if the call trace skip level has not been changed, return the error
-	if s.err.ErrorLocation.skip == 2 {
-		return s.err
+	// if the call trace skip level has been changed, find the location again
+	if s.err.ErrorLocation.skip != 2 {
+		svc := s.err.ErrorLocation.Service
+		s.err.ErrorLocation = callerLocation(s.err.ErrorLocation.skip).withGiven(given)
+		s.err.ErrorLocation.Service = svc
+	}
+	if s.err.ErrorLocation.Page != "" {
+		s.err.ErrorLocation.File = path.Base(s.err.ErrorLocation.Page)
 	}
 
-	pc, s.err.ErrorLocation.Page, s.err.ErrorLocation.Line, _ = runtime.Caller(s.err.ErrorLocation.skip)
-	funcDetails = runtime.FuncForPC(pc)
-
-	s.err.ErrorLocation.Method = funcDetails.Name()
+	// an empty stack is a request to capture one, see CaptureStack
+	if captureStacks.Load() || (s.err.Stack != nil && s.err.Stack.pcs == nil) {
+		s.err.Stack = callers(s.err.ErrorLocation.skip + 1)
+	}
 
 	return s.err
 }
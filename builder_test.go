@@ -33,18 +33,13 @@ func TestNewCloudErrorBuilder(t *testing.T) {
 func Test_cloudErrorBuilder_Build(t *testing.T) {
 	timeNow := time.Now().UTC()
 	want := CloudError{
-		StatusCode: 500,
-		Status:     "Internal Server Error",
-		Message:    "Internal Server Error",
-		Source:     "music-tribe",
-		TimeStamp:  timeNow,
-		CustomCode: InternalServerError,
-		ErrorLocation: ErrorLocation{
-			Method: funcCaller,
-			Page:   builderTestPage,
-			Line:   37,
-			skip:   2,
-		},
+		StatusCode:    500,
+		Status:        "Internal Server Error",
+		Message:       "Internal Server Error",
+		Source:        "music-tribe",
+		TimeStamp:     timeNow,
+		CustomCode:    InternalServerError,
+		ErrorLocation: wantLocation(funcCaller, builderTestPage, 37, 2),
 	}
 
 	setLine := func(se *CloudError) { se.ErrorLocation.Line = 37 }
@@ -87,6 +82,35 @@ func Test_cloudErrorBuilder_Location(t *testing.T) {
 	if got.ErrorLocation.Method != fnc {
 		t.Errorf("cloudErrorBuilder.StatusCode() = %s \nwant %s\n", got.ErrorLocation.Method, fnc)
 	}
+
+	if got.ErrorLocation.Package != testPackage {
+		t.Errorf("cloudErrorBuilder.ErrorLocation() package = %s \nwant %s\n", got.ErrorLocation.Package, testPackage)
+	}
+
+	if got.ErrorLocation.Function != "tRunner" {
+		t.Errorf("cloudErrorBuilder.ErrorLocation() function = %s \nwant %s\n", got.ErrorLocation.Function, "tRunner")
+	}
+}
+
+func Test_cloudErrorBuilder_LocationIsRespected(t *testing.T) {
+	timeNow := time.Now().UTC()
+
+	got := NewCloudErrorBuilder().ErrorLocation("svc-presets", "github.com/music-tribe/svc-presets/storage", "github.com/music-tribe/svc-presets/storage.Upload").Build(timeNow)
+	want := ErrorLocation{
+		Service:  "svc-presets",
+		Package:  "github.com/music-tribe/svc-presets/storage",
+		Function: "Upload",
+		Method:   "github.com/music-tribe/svc-presets/storage.Upload",
+	}
+
+	if got.ErrorLocation.Service != want.Service || got.ErrorLocation.Package != want.Package ||
+		got.ErrorLocation.Function != want.Function || got.ErrorLocation.Method != want.Method {
+		t.Errorf("cloudErrorBuilder.ErrorLocation() = \n%+v \nwant \n%+v\n", got.ErrorLocation, want)
+	}
+
+	if got.ErrorLocation.Page == "" || got.ErrorLocation.File == "" || got.ErrorLocation.Line == 0 {
+		t.Errorf("expected the page, file and line to be filled from the runtime caller but got %+v", got.ErrorLocation)
+	}
 }
 
 func Test_cloudErrorBuilder_CustomCode(t *testing.T) {
@@ -132,20 +156,15 @@ func Test_cloudErrorBuilder_Options(t *testing.T) {
 	}
 
 	want := CloudError{
-		StatusCode: 500,
-		Status:     status,
-		Message:    status,
-		TimeStamp:  timeNow,
-		CustomCode: InternalServerError,
-		Source:     "music-tribe",
-		ErrorLocation: ErrorLocation{
-			Method:  funcCaller,
-			Service: svc,
-			Page:    builderTestPage,
-			Line:    37,
-			skip:    2,
-		},
+		StatusCode:    500,
+		Status:        status,
+		Message:       status,
+		TimeStamp:     timeNow,
+		CustomCode:    InternalServerError,
+		Source:        "music-tribe",
+		ErrorLocation: wantLocation(funcCaller, builderTestPage, 37, 2),
 	}
+	want.ErrorLocation.Service = svc
 
 	if got := NewCloudErrorBuilder().Build(timeNow, options...); !reflect.DeepEqual(*got, want) {
 		t.Errorf("cloudErrorBuilder.StatusCode() = \n%v \nwant \n%v\n", *got, want)
@@ -162,12 +181,7 @@ func Test_cloudErrorBuilder_SkipCaller(t *testing.T) {
 	line := 291
 	skip := 1
 	setSkip := func(se *CloudError) { se.ErrorLocation.skip = skip }
-	el := ErrorLocation{
-		Method: funcCaller,
-		Page:   thisPage,
-		Line:   line,
-		skip:   skip,
-	}
+	el := wantLocation(funcCaller, thisPage, line, skip)
 
 	want := CloudError{
 		StatusCode:    500,
@@ -218,11 +232,21 @@ func Test_cloudErrorBuilder_Error(t *testing.T) {
 		}
 	})
 
-	t.Run("when we pass an error to the Error builder, we should store the message within the CloudError message field", func(t *testing.T) {
+	t.Run("when we pass an error to the Error builder, we should store the message within the CloudError internal message field", func(t *testing.T) {
 		want := errors.New("simple error")
 		got := NewCloudErrorBuilder().Error(want).Build(timeNow)
-		if got.Message != want.Error() {
-			t.Errorf("expected Message to be %s but got %s", want, got.Message)
+		if got.InternalMessage != want.Error() {
+			t.Errorf("expected InternalMessage to be %s but got %s", want, got.InternalMessage)
+		}
+		if got.Message != "Internal Server Error" {
+			t.Errorf("expected Message to be the status text but got %s", got.Message)
+		}
+	})
+
+	t.Run("when we pass an error and a message to the builder, the message should be kept", func(t *testing.T) {
+		got := NewCloudErrorBuilder().Message("preset missing").Error(errors.New("sql: no rows in result set")).Build(timeNow)
+		if got.Message != "preset missing" || got.InternalMessage != "sql: no rows in result set" {
+			t.Errorf("unexpected messages %q %q", got.Message, got.InternalMessage)
 		}
 	})
 
@@ -250,3 +274,29 @@ func Test_cloudErrorBuilder_Error(t *testing.T) {
 		}
 	})
 }
+
+func Test_cloudErrorBuilder_Details(t *testing.T) {
+	timeNow := time.Now().UTC()
+	details := map[string]any{"name": "required"}
+
+	if got := NewCloudErrorBuilder().Details(details).Build(timeNow); !reflect.DeepEqual(got.Details, details) {
+		t.Errorf("cloudErrorBuilder.Details() = %v, \nwant %v\n", got.Details, details)
+	}
+}
+
+func Test_cloudErrorBuilder_Safe(t *testing.T) {
+	t.Run("when an error is built it should not be safe", func(t *testing.T) {
+		if NewCloudErrorBuilder().StatusCode(500).Build(time.Now().UTC()).IsSafe() {
+			t.Error("expected the error not to be safe")
+		}
+	})
+
+	t.Run("when the error is marked safe it should be safe", func(t *testing.T) {
+		if !NewCloudErrorBuilder().StatusCode(503).Safe().Build(time.Now().UTC()).IsSafe() {
+			t.Error("expected the error to be safe")
+		}
+		if !NewCloudError(503, "read only", SafeOption()).IsSafe() {
+			t.Error("expected the error built with SafeOption to be safe")
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package errors
+
+import (
+	errs "errors"
+	"fmt"
+)
+
+// Cause is the serialisable representation of an error chain. Each link holds
+// the message and Go type name of one error in the chain; CloudErrors found in
+// the chain are stored in full so that they survive a round trip intact.
+// Errors that wrap several errors, such as those from errors.Join, record them
+// in Causes instead of Cause.
+type Cause struct {
+	Message    string      `json:"message,omitempty"`
+	Type       string      `json:"type,omitempty"`
+	CloudError *CloudError `json:"cloud_error,omitempty"`
+	Cause      *Cause      `json:"cause,omitempty"`
+	Causes     []*Cause    `json:"causes,omitempty"`
+}
+
+// NewCause walks the chain of err and returns its serialisable form.
+func NewCause(err error) *Cause {
+	if err == nil {
+		return nil
+	}
+
+	if ce, ok := err.(*CloudError); ok {
+		return &Cause{CloudError: ce}
+	}
+
+	c := &Cause{
+		Message: err.Error(),
+		Type:    fmt.Sprintf("%T", err),
+	}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		c.Causes = newCauses(joined.Unwrap())
+		return c
+	}
+
+	next := errs.Unwrap(err)
+
+	// keep the original type name and causes when an already decoded error is
+	// passed on
+	if re, ok := err.(*RemoteError); ok {
+		c.Type = re.Type
+		if rc, ok := next.(remoteCauses); ok {
+			c.Causes = newCauses(rc)
+			return c
+		}
+	}
+
+	c.Cause = NewCause(next)
+	return c
+}
+
+func newCauses(errList []error) []*Cause {
+	var causes []*Cause
+	for _, err := range errList {
+		if err != nil {
+			causes = append(causes, NewCause(err))
+		}
+	}
+	return causes
+}
+
+// Err rebuilds the error chain described by the cause. Links that were
+// CloudErrors are returned as *CloudError, everything else as *RemoteError.
+func (c *Cause) Err() error {
+	if c == nil {
+		return nil
+	}
+	if c.CloudError != nil {
+		return c.CloudError
+	}
+	if c.Message == "" && c.Type == "" && c.Cause == nil && len(c.Causes) == 0 {
+		return nil
+	}
+
+	re := &RemoteError{
+		Message: c.Message,
+		Type:    c.Type,
+		cause:   c.Cause.Err(),
+	}
+	if len(c.Causes) > 0 {
+		causes := make(remoteCauses, 0, len(c.Causes))
+		for _, cause := range c.Causes {
+			if err := cause.Err(); err != nil {
+				causes = append(causes, err)
+			}
+		}
+		re.cause = causes
+	}
+
+	return re
+}
+
+// RemoteError is an error decoded from JSON whose original Go type is not
+// available. Type holds the name of that original type.
+type RemoteError struct {
+	Message string
+	Type    string
+	cause   error
+}
+
+func (re *RemoteError) Error() string {
+	return re.Message
+}
+
+func (re *RemoteError) Unwrap() error {
+	return re.cause
+}
+
+// remoteCauses holds the errors of a decoded link that wrapped several errors,
+// so that errors.Is and errors.As still reach each of them.
+type remoteCauses []error
+
+func (rc remoteCauses) Error() string {
+	return errs.Join(rc...).Error()
+}
+
+func (rc remoteCauses) Unwrap() []error {
+	return rc
+}
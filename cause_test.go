@@ -0,0 +1,233 @@
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewCause(t *testing.T) {
+	When("the error is nil", t,
+		Then("it should return a nil cause", func(t *testing.T) {
+			if got := NewCause(nil); got != nil {
+				t.Errorf("expected nil cause but got %+v", got)
+			}
+		}),
+	)
+
+	When("the error wraps another error", t,
+		Then("it should record each link of the chain", func(t *testing.T) {
+			inner := errors.New("connection refused")
+			outer := fmt.Errorf("dial db: %w", inner)
+
+			want := &Cause{
+				Message: "dial db: connection refused",
+				Type:    "*fmt.wrapError",
+				Cause: &Cause{
+					Message: "connection refused",
+					Type:    "*errors.errorString",
+				},
+			}
+
+			if got := NewCause(outer); !reflect.DeepEqual(got, want) {
+				t.Errorf("NewCause() = \n%+v\nwant \n%+v\n", got, want)
+			}
+		}),
+	)
+
+	When("the error wraps several errors", t,
+		Then("it should record a cause for each of them", func(t *testing.T) {
+			x := errors.New("x")
+			nf := NewCloudError(404, "not found")
+
+			got := NewCause(fmt.Errorf("a: %w; b: %w", x, nf))
+			if got.Cause != nil || len(got.Causes) != 2 {
+				t.Fatalf("expected two causes but got %+v", got)
+			}
+			if got.Causes[0].Message != "x" || got.Causes[1].CloudError != nf {
+				t.Errorf("unexpected causes %+v, %+v", got.Causes[0], got.Causes[1])
+			}
+		}),
+	)
+
+	When("the error is a decoded remote error", t,
+		Then("it should keep the original type name", func(t *testing.T) {
+			re := &RemoteError{Message: "boom", Type: "*pq.Error"}
+			if got := NewCause(re); got.Type != "*pq.Error" {
+				t.Errorf("expected type to be *pq.Error but got %s", got.Type)
+			}
+		}),
+	)
+}
+
+func TestCause_Err(t *testing.T) {
+	When("the cause is empty", t,
+		Then("it should return a nil error", func(t *testing.T) {
+			if got := (&Cause{}).Err(); got != nil {
+				t.Errorf("expected nil error but got %v", got)
+			}
+		}),
+	)
+
+	When("the cause holds a chain", t,
+		Then("the rebuilt error should keep each message and type", func(t *testing.T) {
+			c := &Cause{
+				Message: "dial db: connection refused",
+				Type:    "*fmt.wrapError",
+				Cause: &Cause{
+					Message: "connection refused",
+					Type:    "*net.OpError",
+				},
+			}
+
+			err := c.Err()
+			if err.Error() != c.Message {
+				t.Errorf("expected message to be %s but got %s", c.Message, err.Error())
+			}
+
+			var re *RemoteError
+			if !errors.As(errors.Unwrap(err), &re) {
+				t.Fatalf("expected the unwrapped error to be a *RemoteError")
+			}
+			if re.Type != "*net.OpError" || re.Message != "connection refused" {
+				t.Errorf("unexpected inner error %+v", re)
+			}
+		}),
+	)
+}
+
+func TestCloudError_JSONRoundTrip(t *testing.T) {
+	When("a cloud error wrapping a standard error is marshalled and unmarshalled", t,
+		Then("the internal error chain should survive", func(t *testing.T) {
+			sentinel := errors.New("no rows in result set")
+			ce := NewCloudError(404, fmt.Errorf("get user: %w", sentinel))
+
+			byt, err := json.Marshal(ce)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := new(CloudError)
+			if err := json.Unmarshal(byt, got); err != nil {
+				t.Fatal(err)
+			}
+
+			if got.InternalError == nil {
+				t.Fatalf("expected internal error to be rebuilt")
+			}
+			if got.InternalError.Error() != "get user: no rows in result set" {
+				t.Errorf("unexpected internal error message %s", got.InternalError.Error())
+			}
+
+			var re *RemoteError
+			if !errors.As(errors.Unwrap(got.InternalError), &re) || re.Type != "*errors.errorString" {
+				t.Errorf("expected the innermost cause to be a *errors.errorString remote error but got %+v", re)
+			}
+		}),
+	)
+
+	When("a cloud error wraps another cloud error", t,
+		Then("the nested cloud error should be rebuilt as a CloudError", func(t *testing.T) {
+			inner := NewCloudErrorBuilder().
+				StatusCode(404).
+				CorrelationID("abc").
+				Error(errors.New("blob missing")).
+				Build(time.Now().UTC())
+			outer := NewCloudError(502, inner)
+
+			byt, err := json.Marshal(outer)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := new(CloudError)
+			if err := json.Unmarshal(byt, got); err != nil {
+				t.Fatal(err)
+			}
+
+			var nested *CloudError
+			if !errors.As(got.InternalError, &nested) {
+				t.Fatalf("expected internal error to be a *CloudError but got %T", got.InternalError)
+			}
+			if nested.StatusCode != 404 || nested.CustomCode != NotFound || nested.CorrelationID != "abc" {
+				t.Errorf("unexpected nested cloud error %+v", nested)
+			}
+			if !errors.Is(got, &CloudError{CustomCode: NotFound}) {
+				t.Errorf("expected errors.Is to find the nested NotFound error")
+			}
+			if nested.InternalError == nil || nested.InternalError.Error() != "blob missing" {
+				t.Errorf("expected nested internal error to be rebuilt but got %v", nested.InternalError)
+			}
+		}),
+	)
+
+	When("a cloud error wraps a joined error that holds another cloud error", t,
+		Then("every branch of the chain should survive", func(t *testing.T) {
+			sentinel := errors.New("timeout")
+			outer := NewCloudError(500, fmt.Errorf("a: %w; b: %w", sentinel, NewCloudError(404, "missing")))
+
+			byt, err := json.Marshal(outer)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := new(CloudError)
+			if err := json.Unmarshal(byt, got); err != nil {
+				t.Fatal(err)
+			}
+
+			if got.InternalError == nil || got.InternalError.Error() != "a: timeout; b: 404 NotFound: missing" {
+				t.Fatalf("unexpected internal error %v", got.InternalError)
+			}
+
+			var nested *CloudError
+			if !errors.As(got.InternalError, &nested) || nested.StatusCode != 404 {
+				t.Errorf("expected the nested 404 cloud error to be rebuilt but got %+v", nested)
+			}
+
+			// a second round trip should give the same chain
+			again, err := json.Marshal(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(again) != string(byt) {
+				t.Errorf("expected a stable round trip but got \n%s\nwant \n%s", again, byt)
+			}
+		}),
+	)
+
+	When("the cloud error has no internal error", t,
+		Then("the internal_error field should be omitted", func(t *testing.T) {
+			byt, err := json.Marshal(NewCloudError(400, "bad"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			m := map[string]any{}
+			if err := json.Unmarshal(byt, &m); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := m["internal_error"]; ok {
+				t.Errorf("expected internal_error to be omitted but got %v", m["internal_error"])
+			}
+		}),
+	)
+
+	When("a legacy payload with an empty internal_error object is unmarshalled", t,
+		Then("the internal error should be nil", func(t *testing.T) {
+			got := new(CloudError)
+			if err := json.Unmarshal([]byte(`{"status_code":500,"internal_error":{}}`), got); err != nil {
+				t.Fatal(err)
+			}
+			if got.InternalError != nil {
+				t.Errorf("expected nil internal error but got %v", got.InternalError)
+			}
+			if got.StatusCode != 500 {
+				t.Errorf("expected status code to be 500 but got %d", got.StatusCode)
+			}
+		}),
+	)
+}
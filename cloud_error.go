@@ -2,6 +2,8 @@ package errors
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"time"
 )
 
@@ -10,33 +12,158 @@ const (
 	NotFound            CustomCode = "NotFound"
 )
 
+// CloudError is an error that can be sent to clients. Message is the text
+// meant for them, while InternalMessage holds the text of the underlying
+// error for developers. MessageKey and MessageArgs identify the message in a
+// Catalog so that it can be translated. Items holds the errors of the items
+// of a batch request when the error stands for a CloudErrorList.
 type CloudError struct {
-	StatusCode    int           `json:"status_code"`
-	Status        string        `json:"status"`
-	Message       string        `json:"message"`
-	Source        string        `json:"source"`
-	TimeStamp     time.Time     `json:"timestamp"`
-	CustomCode    CustomCode    `json:"custom_code"`
-	ErrorLocation ErrorLocation `json:"location,omitempty"`
-	CorrelationID string        `json:"correlation_id"`
-	Tags          []string      `json:"tags,omitempty"`
-	InternalError error         `json:"internal_error"`
+	StatusCode      int              `json:"status_code"`
+	Status          string           `json:"status"`
+	Message         string           `json:"message"`
+	InternalMessage string           `json:"internal_message,omitempty"`
+	MessageKey      string           `json:"message_key,omitempty"`
+	MessageArgs     map[string]any   `json:"message_args,omitempty"`
+	Source          string           `json:"source"`
+	TimeStamp       time.Time        `json:"timestamp"`
+	CustomCode      CustomCode       `json:"custom_code"`
+	ErrorLocation   ErrorLocation    `json:"location,omitempty"`
+	CorrelationID   string           `json:"correlation_id"`
+	UserID          string           `json:"user_id,omitempty"`
+	TenantID        string           `json:"tenant_id,omitempty"`
+	Tags            []string         `json:"tags,omitempty"`
+	Details         any              `json:"details,omitempty"`
+	FieldErrors     FieldErrors      `json:"field_errors,omitempty"`
+	Items           []CloudErrorItem `json:"items,omitempty"`
+	InternalError   error            `json:"internal_error,omitempty"`
+	Stack           *Stack           `json:"stack,omitempty"`
+
+	safe bool
 }
 
+// ErrorLocation is where in the code an error was built. Method and Page hold
+// the fully qualified function name and full file path; Package, Function and
+// File hold the same information broken down.
 type ErrorLocation struct {
-	Service string `json:"service,omitempty"`
-	Method  string `json:"method,omitempty"`
-	Page    string `json:"page,omitempty"`
-	Line    int    `json:"line,omitempty"`
-	skip    int    `json:"-"`
+	Service  string `json:"service,omitempty"`
+	Package  string `json:"package,omitempty"`
+	Function string `json:"function,omitempty"`
+	File     string `json:"file,omitempty"`
+	Method   string `json:"method,omitempty"`
+	Page     string `json:"page,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	skip     int    `json:"-"`
 }
 
 type CustomCode string
 
+// Error returns the error in the format set with SetErrorFormat, a single
+// line of text by default.
 func (se *CloudError) Error() string {
-	byt, _ := json.MarshalIndent(se, "", "  ")
+	switch ErrorFormat(errorFormat.Load()) {
+	case FormatJSON:
+		return se.json(false)
+	case FormatIndentedJSON:
+		return se.json(true)
+	default:
+		return se.text()
+	}
+}
+
+// Format implements fmt.Formatter. The %v and %s verbs print Error(), %+v
+// prints every field as indented JSON followed by the stack trace, if one was
+// captured, and %#v prints the Go syntax representation.
+func (se *CloudError) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if f.Flag('#') {
+			fmt.Fprintf(f, "&%#v", *se)
+			return
+		}
+
+		if !f.Flag('+') {
+			_, _ = io.WriteString(f, se.Error())
+			return
+		}
+
+		// the stack is printed frame by frame below, so it is left out of the
+		// JSON rather than printed twice
+		full := *se
+		full.Stack = nil
+		_, _ = io.WriteString(f, full.json(true))
+		for _, frame := range se.StackTrace() {
+			fmt.Fprintf(f, "\n%s\n\t%s:%d", frame.Function, frame.File, frame.Line)
+		}
+	case 's':
+		_, _ = io.WriteString(f, se.Error())
+	case 'q':
+		fmt.Fprintf(f, "%q", se.Error())
+	}
+}
+
+// MarshalJSON encodes the error, serialising InternalError as a Cause so that
+// its message, type and any nested CloudErrors are kept.
+func (se *CloudError) MarshalJSON() ([]byte, error) {
+	type cloudError CloudError
+	return json.Marshal(&struct {
+		*cloudError
+		InternalError *Cause `json:"internal_error,omitempty"`
+	}{
+		cloudError:    (*cloudError)(se),
+		InternalError: NewCause(se.InternalError),
+	})
+}
+
+// UnmarshalJSON decodes an error produced by MarshalJSON, rebuilding the
+// InternalError chain from its Cause.
+func (se *CloudError) UnmarshalJSON(data []byte) error {
+	type cloudError CloudError
+	aux := &struct {
+		*cloudError
+		InternalError *Cause `json:"internal_error,omitempty"`
+	}{
+		cloudError: (*cloudError)(se),
+	}
+	if err := json.Unmarshal(data, aux); err != nil {
+		return err
+	}
+
+	se.InternalError = aux.InternalError.Err()
+	return nil
+}
+
+// IsSafe reports whether the message of the error was marked as safe to send
+// to clients even when it is a server error. The mark is not kept when the
+// error is encoded.
+func (se *CloudError) IsSafe() bool {
+	return se.safe
+}
+
+// Unwrap returns the wrapped InternalError so that errors.Is and errors.As
+// can inspect the underlying cause.
+func (se *CloudError) Unwrap() error {
+	return se.InternalError
+}
 
-	return string(byt)
+// Is reports whether target is a CloudError with a matching CustomCode and/or
+// StatusCode. Zero-valued fields on target are ignored, so
+// &CloudError{CustomCode: NotFound} matches any NotFound error regardless of
+// its status code.
+func (se *CloudError) Is(target error) bool {
+	t, ok := target.(*CloudError)
+	if !ok || t == nil {
+		return false
+	}
+	if t.CustomCode == "" && t.StatusCode == 0 {
+		return false
+	}
+	if t.CustomCode != "" && t.CustomCode != se.CustomCode {
+		return false
+	}
+	if t.StatusCode != 0 && t.StatusCode != se.StatusCode {
+		return false
+	}
+	return true
 }
 
 type CloudErrorOption func(*CloudError)
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"path"
 	"reflect"
 	"runtime"
 	"testing"
@@ -27,12 +28,7 @@ func TestNewCloudError(t *testing.T) {
 				rtFunc := runtime.FuncForPC(pc)
 				line := 37
 				setLine := func(se *CloudError) { se.ErrorLocation.Line = line }
-				el := ErrorLocation{
-					Method: rtFunc.Name(),
-					Page:   testPage,
-					Line:   line,
-					skip:   2,
-				}
+				el := wantLocation(rtFunc.Name(), testPage, line, 2)
 
 				setTimeOpt := func(se *CloudError) { se.TimeStamp = timeNow }
 				want := CloudError{
@@ -65,12 +61,7 @@ func TestNewCloudError(t *testing.T) {
 			rtFunc := runtime.FuncForPC(pc)
 			line := 37
 			setLine := func(se *CloudError) { se.ErrorLocation.Line = line }
-			el := ErrorLocation{
-				Method: rtFunc.Name(),
-				Page:   testPage,
-				Line:   line,
-				skip:   2,
-			}
+			el := wantLocation(rtFunc.Name(), testPage, line, 2)
 
 			setTimeOpt := func(se *CloudError) { se.TimeStamp = timeNow }
 			want := CloudError{
@@ -101,12 +92,7 @@ func TestNewCloudError(t *testing.T) {
 			rtFunc := runtime.FuncForPC(pc)
 			line := 37
 			setLine := func(se *CloudError) { se.ErrorLocation.Line = line }
-			el := ErrorLocation{
-				Method: rtFunc.Name(),
-				Page:   testPage,
-				Line:   line,
-				skip:   2,
-			}
+			el := wantLocation(rtFunc.Name(), testPage, line, 2)
 
 			setTimeOpt := func(se *CloudError) { se.TimeStamp = timeNow }
 			want := CloudError{
@@ -137,12 +123,7 @@ func TestNewCloudError(t *testing.T) {
 			rtFunc := runtime.FuncForPC(pc)
 			line := 37
 			setLine := func(se *CloudError) { se.ErrorLocation.Line = line }
-			el := ErrorLocation{
-				Method: rtFunc.Name(),
-				Page:   testPage,
-				Line:   line,
-				skip:   2,
-			}
+			el := wantLocation(rtFunc.Name(), testPage, line, 2)
 
 			setTimeOpt := func(se *CloudError) { se.TimeStamp = timeNow }
 			want := CloudError{
@@ -224,8 +205,7 @@ func TestNewCloudError(t *testing.T) {
 			pc, _, _line, _ := runtime.Caller(1)
 			got := NewCloudError(wantSc, "", setTimeOpt, setSkip)
 
-			want.ErrorLocation.Line = _line
-			want.ErrorLocation.Method = runtime.FuncForPC(pc).Name()
+			want.ErrorLocation = wantLocation(runtime.FuncForPC(pc).Name(), testPage, _line, skip)
 
 			if !reflect.DeepEqual(*got, want) {
 				t.Errorf("NewCloudError() = \n%v\n but want \n%v\n", *got, want)
@@ -234,10 +214,15 @@ func TestNewCloudError(t *testing.T) {
 	)
 
 	When("we pass an error as the argument", t,
-		Then("the errors original message should be in the error", func(t *testing.T) {
+		Then("the errors original message should be the internal message", func(t *testing.T) {
 			wantMsg := "simple error"
-			if got := NewCloudError(400, errors.New(wantMsg)); got.Message != wantMsg {
-				t.Errorf("expected Message to be '%s' but got '%s'", wantMsg, got.Message)
+			if got := NewCloudError(400, errors.New(wantMsg)); got.InternalMessage != wantMsg {
+				t.Errorf("expected InternalMessage to be '%s' but got '%s'", wantMsg, got.InternalMessage)
+			}
+		}),
+		And("the message should be the status text", func(t *testing.T) {
+			if got := NewCloudError(400, errors.New("simple error")); got.Message != "Bad Request" {
+				t.Errorf("expected Message to be 'Bad Request' but got '%s'", got.Message)
 			}
 		}),
 		And("the error should be stored as an internal error", func(t *testing.T) {
@@ -250,6 +235,22 @@ func TestNewCloudError(t *testing.T) {
 	)
 }
 
+// wantLocation builds the ErrorLocation expected for an error built in method,
+// including the fields that Build derives from it.
+func wantLocation(method, page string, line, skip int) ErrorLocation {
+	pkg, fnc := splitFuncName(method)
+	return ErrorLocation{
+		Service:  ServiceName(),
+		Package:  pkg,
+		Function: fnc,
+		File:     path.Base(page),
+		Method:   method,
+		Page:     page,
+		Line:     line,
+		skip:     skip,
+	}
+}
+
 func When(description string, t *testing.T, then ...func(t *testing.T)) {
 	// return func(t *testing.T) {
 	t.Run(fmt.Sprintf("When %s\n", description), func(t *testing.T) {
@@ -291,3 +292,117 @@ func TestQuick(t *testing.T) {
 		t.Errorf("something stupid happened: %v st err = \n%+v\n", err, se)
 	}
 }
+
+type testCauseError struct {
+	code int
+}
+
+func (e *testCauseError) Error() string {
+	return fmt.Sprintf("test cause %d", e.code)
+}
+
+func TestCloudError_Unwrap(t *testing.T) {
+	sentinel := errors.New("no rows in result set")
+
+	When("the cloud error wraps an internal error", t,
+		Then("errors.Is should find the wrapped sentinel", func(t *testing.T) {
+			ce := NewCloudError(404, fmt.Errorf("get user: %w", sentinel))
+			if !errors.Is(ce, sentinel) {
+				t.Errorf("expected errors.Is to find %v in %v", sentinel, ce.InternalError)
+			}
+		}),
+		And("errors.As should find the wrapped error type", func(t *testing.T) {
+			ce := NewCloudError(500, &testCauseError{code: 7})
+			var cause *testCauseError
+			if !errors.As(ce, &cause) {
+				t.Fatalf("expected errors.As to find a *testCauseError")
+			}
+			if cause.code != 7 {
+				t.Errorf("expected cause code to be 7 but got %d", cause.code)
+			}
+		}),
+		And("errors.As should find a cloud error wrapped by a standard error", func(t *testing.T) {
+			err := fmt.Errorf("handler: %w", NewCloudError(403, "nope"))
+			var ce *CloudError
+			if !errors.As(err, &ce) {
+				t.Fatalf("expected errors.As to find a *CloudError")
+			}
+			if ce.StatusCode != 403 {
+				t.Errorf("expected status code to be 403 but got %d", ce.StatusCode)
+			}
+		}),
+	)
+
+	When("the cloud error has no internal error", t,
+		Then("Unwrap should return nil", func(t *testing.T) {
+			if got := NewCloudError(400, "bad").Unwrap(); got != nil {
+				t.Errorf("expected Unwrap to return nil but got %v", got)
+			}
+		}),
+	)
+}
+
+func TestCloudError_Is(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		target error
+		want   bool
+	}{
+		{
+			name:   "same custom code matches",
+			err:    NewCloudError(404, "user missing"),
+			target: &CloudError{CustomCode: NotFound},
+			want:   true,
+		},
+		{
+			name:   "different custom code does not match",
+			err:    NewCloudError(404, "user missing"),
+			target: &CloudError{CustomCode: InternalServerError},
+			want:   false,
+		},
+		{
+			name:   "same status code matches",
+			err:    NewCloudError(403, "nope"),
+			target: &CloudError{StatusCode: 403},
+			want:   true,
+		},
+		{
+			name:   "custom code and status code must both match",
+			err:    NewCloudError(404, "user missing"),
+			target: &CloudError{StatusCode: 410, CustomCode: NotFound},
+			want:   false,
+		},
+		{
+			name:   "two built cloud errors with the same code match",
+			err:    NewCloudError(404, "user missing"),
+			target: NewCloudError(404, "preset missing"),
+			want:   true,
+		},
+		{
+			name:   "an empty target never matches",
+			err:    NewCloudError(404, "user missing"),
+			target: &CloudError{},
+			want:   false,
+		},
+		{
+			name:   "a wrapped cloud error matches",
+			err:    fmt.Errorf("handler: %w", NewCloudError(404, "user missing")),
+			target: &CloudError{CustomCode: NotFound},
+			want:   true,
+		},
+		{
+			name:   "a non cloud error target does not match",
+			err:    NewCloudError(404, "user missing"),
+			target: errors.New("Not Found"),
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(tt.err, tt.target); got != tt.want {
+				t.Errorf("errors.Is() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
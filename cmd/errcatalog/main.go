@@ -0,0 +1,69 @@
+// Command errcatalog statically scans a Go module for the CustomCodes it can
+// return and writes a catalogue of them.
+//
+// Usage:
+//
+//	errcatalog [-format markdown|json|openapi] [-o file] [-tests] [dir]
+//
+// Codes are collected from CustomCode constants, CodeDefinition literals, and
+// calls to NewCloudError, NewCloudErrorFromCode and
+// NewCloudErrorBuilder()...Build() chains. dir defaults to the current
+// directory.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "errcatalog:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("errcatalog", flag.ContinueOnError)
+	format := fs.String("format", "markdown", "output format: markdown, json or openapi")
+	out := fs.String("o", "", "write the catalogue to this file instead of stdout")
+	tests := fs.Bool("tests", false, "include _test.go files")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	render, ok := renderers[*format]
+	if !ok {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	root := "."
+	if fs.NArg() > 0 {
+		root = fs.Arg(0)
+	}
+
+	entries, err := Scan(root, *tests)
+	if err != nil {
+		return err
+	}
+
+	w := stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	return render(w, entries)
+}
+
+var renderers = map[string]func(io.Writer, []*Entry) error{
+	"markdown": renderMarkdown,
+	"json":     renderJSON,
+	"openapi":  renderOpenAPI,
+}
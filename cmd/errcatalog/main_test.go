@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	t.Run("markdown", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := run([]string{"testdata/svc"}, &out); err != nil {
+			t.Fatal(err)
+		}
+
+		want := "| `PresetLocked` | 409 | the preset is locked | Returned when a preset is edited while locked. |  | " +
+			"`presets/codes.go:11`<br>`presets/codes.go:17`<br>`presets/codes.go:27` |\n"
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("want markdown to contain \n%s\nbut got \n%s\n", want, out.String())
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := run([]string{"-format", "json", "testdata/svc"}, &out); err != nil {
+			t.Fatal(err)
+		}
+
+		var entries []*Entry
+		if err := json.Unmarshal(out.Bytes(), &entries); err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 6 || entries[0].Code != "BadRequest" {
+			t.Errorf("unexpected entries %+v", entries)
+		}
+	})
+
+	t.Run("openapi", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := run([]string{"-format", "openapi", "testdata/svc"}, &out); err != nil {
+			t.Fatal(err)
+		}
+
+		var doc struct {
+			Components struct {
+				Schemas struct {
+					CustomCode struct {
+						Enum []string `json:"enum"`
+					} `json:"CustomCode"`
+				} `json:"schemas"`
+				Examples map[string]struct {
+					Value struct {
+						StatusCode int    `json:"status_code"`
+						Message    string `json:"message"`
+					} `json:"value"`
+				} `json:"examples"`
+			} `json:"components"`
+		}
+		if err := json.Unmarshal(out.Bytes(), &doc); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(doc.Components.Schemas.CustomCode.Enum) != 6 {
+			t.Errorf("want 6 codes in the enum but got %v", doc.Components.Schemas.CustomCode.Enum)
+		}
+		ex := doc.Components.Examples["PresetTooBig"]
+		if ex.Value.StatusCode != 413 || ex.Value.Message != "Request Entity Too Large" {
+			t.Errorf("unexpected example %+v", ex)
+		}
+	})
+
+	t.Run("output file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "catalogue.md")
+		if err := run([]string{"-o", path, "testdata/svc"}, &bytes.Buffer{}); err != nil {
+			t.Fatal(err)
+		}
+
+		byt, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(byt), "# Error Catalogue") {
+			t.Errorf("unexpected file contents %s", byt)
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		if err := run([]string{"-format", "yaml", "testdata/svc"}, &bytes.Buffer{}); err == nil {
+			t.Errorf("want an error for an unknown format")
+		}
+	})
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func renderJSON(w io.Writer, entries []*Entry) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+func renderMarkdown(w io.Writer, entries []*Entry) error {
+	var b strings.Builder
+
+	b.WriteString("# Error Catalogue\n\n")
+	b.WriteString("| Code | Status | Message | Description | Retryable | Locations |\n")
+	b.WriteString("| ---- | ------ | ------- | ----------- | --------- | --------- |\n")
+	for _, e := range entries {
+		statuses := make([]string, 0, len(e.StatusCodes))
+		for _, sc := range e.StatusCodes {
+			statuses = append(statuses, strconv.Itoa(sc))
+		}
+		locations := make([]string, 0, len(e.Locations))
+		for _, l := range e.Locations {
+			locations = append(locations, fmt.Sprintf("`%s:%d`", l.File, l.Line))
+		}
+		retryable := ""
+		if e.Retryable {
+			retryable = "yes"
+		}
+
+		fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %s | %s |\n",
+			e.Code,
+			strings.Join(statuses, ", "),
+			markdownCell(e.Message),
+			markdownCell(e.Description),
+			retryable,
+			strings.Join(locations, "<br>"),
+		)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+// renderOpenAPI writes an OpenAPI 3 document containing only components: a
+// CustomCode enum, the CloudError schema and an example per code, ready to be
+// merged into a service's API description.
+func renderOpenAPI(w io.Writer, entries []*Entry) error {
+	codes := make([]string, 0, len(entries))
+	examples := map[string]any{}
+	for _, e := range entries {
+		codes = append(codes, e.Code)
+
+		sc := e.StatusCodes[0]
+		msg := e.Message
+		if msg == "" {
+			msg = http.StatusText(sc)
+		}
+
+		desc := e.Description
+		if desc == "" {
+			desc = fmt.Sprintf("Returned with status %s.", joinInts(e.StatusCodes))
+		}
+
+		examples[e.Code] = map[string]any{
+			"summary":     e.Code,
+			"description": desc,
+			"value": map[string]any{
+				"status_code": sc,
+				"status":      http.StatusText(sc),
+				"message":     msg,
+				"custom_code": e.Code,
+			},
+		}
+	}
+
+	doc := map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "Error Catalogue",
+			"version": "1.0.0",
+		},
+		"paths": map[string]any{},
+		"components": map[string]any{
+			"schemas": map[string]any{
+				"CustomCode": map[string]any{
+					"type": "string",
+					"enum": codes,
+				},
+				"CloudError": cloudErrorSchema,
+			},
+			"examples": examples,
+		},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+var cloudErrorSchema = map[string]any{
+	"type":     "object",
+	"required": []string{"status_code", "status", "message", "custom_code"},
+	"properties": map[string]any{
+		"status_code":    map[string]any{"type": "integer"},
+		"status":         map[string]any{"type": "string"},
+		"message":        map[string]any{"type": "string"},
+		"source":         map[string]any{"type": "string"},
+		"timestamp":      map[string]any{"type": "string", "format": "date-time"},
+		"custom_code":    map[string]any{"$ref": "#/components/schemas/CustomCode"},
+		"correlation_id": map[string]any{"type": "string"},
+		"tags":           map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+	},
+}
+
+func joinInts(ints []int) string {
+	s := make([]string, 0, len(ints))
+	for _, i := range ints {
+		s = append(s, strconv.Itoa(i))
+	}
+	return strings.Join(s, ", ")
+}
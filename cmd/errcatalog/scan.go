@@ -0,0 +1,421 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const errorsImportPath = "github.com/music-tribe/errors"
+
+// Location is a place in the source where a code is declared or returned.
+type Location struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+	Kind string `json:"kind"`
+}
+
+// Entry is everything the scanner found out about a single CustomCode.
+type Entry struct {
+	Code        string     `json:"code"`
+	StatusCodes []int      `json:"status_codes"`
+	Message     string     `json:"message,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Retryable   bool       `json:"retryable,omitempty"`
+	Locations   []Location `json:"locations"`
+}
+
+const (
+	kindConstant   = "constant"
+	kindDefinition = "definition"
+	kindCall       = "call"
+)
+
+type scanner struct {
+	fset         *token.FileSet
+	root         string
+	includeTests bool
+
+	// constants maps a constant name onto its string value. Names are not
+	// qualified by package, which is accurate enough for a catalogue.
+	constants map[string]string
+	entries   map[string]*Entry
+}
+
+// Scan walks the Go files below root and returns the catalogue of every
+// CustomCode it finds, sorted by code.
+func Scan(root string, includeTests bool) ([]*Entry, error) {
+	s := &scanner{
+		fset:         token.NewFileSet(),
+		root:         root,
+		includeTests: includeTests,
+		constants:    map[string]string{},
+		entries:      map[string]*Entry{},
+	}
+
+	files, err := s.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	// constants are collected first so that call sites in any file can refer
+	// to them
+	for _, f := range files {
+		s.collectConstants(f)
+	}
+	for _, f := range files {
+		s.collectUsages(f)
+	}
+
+	return s.catalogue(), nil
+}
+
+func (s *scanner) parse() ([]*ast.File, error) {
+	var files []*ast.File
+	err := filepath.WalkDir(s.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name := d.Name()
+		if d.IsDir() {
+			if path != s.root && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(name, ".go") || (!s.includeTests && strings.HasSuffix(name, "_test.go")) {
+			return nil
+		}
+
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		f, err := parser.ParseFile(s.fset, path, src, 0)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, f)
+		return nil
+	})
+
+	return files, err
+}
+
+// errorsPackageNames returns the names by which the errors package can be
+// referred to in f. An empty name means its identifiers are used unqualified,
+// which is the case within the errors package itself.
+func errorsPackageNames(f *ast.File) map[string]bool {
+	names := map[string]bool{}
+	for _, imp := range f.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+		if path != errorsImportPath {
+			continue
+		}
+		if imp.Name != nil {
+			names[imp.Name.Name] = true
+		} else {
+			names["errors"] = true
+		}
+	}
+	if len(names) == 0 && f.Name.Name == "errors" {
+		names[""] = true
+	}
+
+	return names
+}
+
+// isErrorsIdent reports whether expr refers to name in the errors package.
+func isErrorsIdent(expr ast.Expr, name string, pkgNames map[string]bool) bool {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return pkgNames[""] && e.Name == name
+	case *ast.SelectorExpr:
+		x, ok := e.X.(*ast.Ident)
+		return ok && pkgNames[x.Name] && e.Sel.Name == name
+	}
+	return false
+}
+
+func (s *scanner) collectConstants(f *ast.File) {
+	pkgNames := errorsPackageNames(f)
+	if len(pkgNames) == 0 {
+		return
+	}
+
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+
+		for _, spec := range gen.Specs {
+			vs := spec.(*ast.ValueSpec)
+			if vs.Type == nil || !isErrorsIdent(vs.Type, "CustomCode", pkgNames) {
+				continue
+			}
+
+			for i, name := range vs.Names {
+				if i >= len(vs.Values) {
+					continue
+				}
+				value, ok := stringLiteral(vs.Values[i])
+				if !ok {
+					continue
+				}
+
+				s.constants[name.Name] = value
+				s.add(value, 0, name.Pos(), kindConstant)
+			}
+		}
+	}
+}
+
+func (s *scanner) collectUsages(f *ast.File) {
+	pkgNames := errorsPackageNames(f)
+	if len(pkgNames) == 0 {
+		return
+	}
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.CompositeLit:
+			if isErrorsIdent(node.Type, "CodeDefinition", pkgNames) {
+				s.definition(node, pkgNames)
+			}
+
+			// element types may be elided in []errors.CodeDefinition{{...}}
+			if arr, ok := node.Type.(*ast.ArrayType); ok && isErrorsIdent(arr.Elt, "CodeDefinition", pkgNames) {
+				for _, elt := range node.Elts {
+					if lit, ok := elt.(*ast.CompositeLit); ok && lit.Type == nil {
+						s.definition(lit, pkgNames)
+					}
+				}
+			}
+		case *ast.CallExpr:
+			switch {
+			case isErrorsIdent(node.Fun, "NewCloudError", pkgNames):
+				if len(node.Args) > 0 {
+					if sc, ok := statusCode(node.Args[0]); ok {
+						s.add(derivedCode(sc), sc, node.Pos(), kindCall)
+					}
+				}
+			case isErrorsIdent(node.Fun, "NewCloudErrorFromCode", pkgNames):
+				if len(node.Args) > 0 {
+					if code, ok := s.customCode(node.Args[0], pkgNames); ok {
+						s.add(code, 0, node.Pos(), kindCall)
+					}
+				}
+			default:
+				s.builderChain(node, pkgNames)
+			}
+		}
+		return true
+	})
+}
+
+func (s *scanner) definition(lit *ast.CompositeLit, pkgNames map[string]bool) {
+	var (
+		code, msg, desc string
+		sc              int
+		retryable       bool
+	)
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+		key, ok := kv.Key.(*ast.Ident)
+		if !ok {
+			continue
+		}
+
+		switch key.Name {
+		case "Code":
+			code, _ = s.customCode(kv.Value, pkgNames)
+		case "StatusCode":
+			sc, _ = statusCode(kv.Value)
+		case "Message":
+			msg, _ = stringLiteral(kv.Value)
+		case "Description":
+			desc, _ = stringLiteral(kv.Value)
+		case "Retryable":
+			ident, ok := kv.Value.(*ast.Ident)
+			retryable = ok && ident.Name == "true"
+		}
+	}
+	if code == "" {
+		return
+	}
+
+	e := s.add(code, sc, lit.Pos(), kindDefinition)
+	e.Message = msg
+	e.Description = desc
+	e.Retryable = retryable
+}
+
+// builderChain records the code and status code of a
+// NewCloudErrorBuilder()...Build() chain.
+func (s *scanner) builderChain(call *ast.CallExpr, pkgNames map[string]bool) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Build" {
+		return
+	}
+
+	var (
+		code     string
+		sc       int
+		hasCode  bool
+		fromRoot bool
+	)
+	for expr := sel.X; ; {
+		c, ok := expr.(*ast.CallExpr)
+		if !ok {
+			break
+		}
+		if isErrorsIdent(c.Fun, "NewCloudErrorBuilder", pkgNames) {
+			fromRoot = true
+			break
+		}
+
+		method, ok := c.Fun.(*ast.SelectorExpr)
+		if !ok {
+			break
+		}
+		switch {
+		case method.Sel.Name == "CustomCode" && len(c.Args) == 1 && !hasCode:
+			code, hasCode = s.customCode(c.Args[0], pkgNames)
+		case method.Sel.Name == "StatusCode" && len(c.Args) == 1 && sc == 0:
+			sc, _ = statusCode(c.Args[0])
+		}
+		expr = method.X
+	}
+	if !fromRoot {
+		return
+	}
+
+	switch {
+	case hasCode:
+		s.add(code, sc, call.Pos(), kindCall)
+	case sc != 0:
+		s.add(derivedCode(sc), sc, call.Pos(), kindCall)
+	}
+}
+
+// customCode resolves a CustomCode argument: a string literal, a constant, or
+// a conversion such as errors.CustomCode("X").
+func (s *scanner) customCode(expr ast.Expr, pkgNames map[string]bool) (string, bool) {
+	if v, ok := stringLiteral(expr); ok {
+		return v, true
+	}
+
+	switch e := expr.(type) {
+	case *ast.Ident:
+		v, ok := s.constants[e.Name]
+		return v, ok
+	case *ast.SelectorExpr:
+		v, ok := s.constants[e.Sel.Name]
+		return v, ok
+	case *ast.CallExpr:
+		if len(e.Args) == 1 && isErrorsIdent(e.Fun, "CustomCode", pkgNames) {
+			return stringLiteral(e.Args[0])
+		}
+	}
+	return "", false
+}
+
+func (s *scanner) add(code string, sc int, pos token.Pos, kind string) *Entry {
+	e, ok := s.entries[code]
+	if !ok {
+		e = &Entry{Code: code}
+		s.entries[code] = e
+	}
+
+	if sc != 0 && !containsInt(e.StatusCodes, sc) {
+		e.StatusCodes = append(e.StatusCodes, sc)
+	}
+
+	p := s.fset.Position(pos)
+	file, err := filepath.Rel(s.root, p.Filename)
+	if err != nil {
+		file = p.Filename
+	}
+	e.Locations = append(e.Locations, Location{File: filepath.ToSlash(file), Line: p.Line, Kind: kind})
+
+	return e
+}
+
+func (s *scanner) catalogue() []*Entry {
+	entries := make([]*Entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		if len(e.StatusCodes) == 0 {
+			e.StatusCodes = []int{http.StatusInternalServerError}
+		}
+		sort.Ints(e.StatusCodes)
+		sort.Slice(e.Locations, func(i, j int) bool {
+			if e.Locations[i].File != e.Locations[j].File {
+				return e.Locations[i].File < e.Locations[j].File
+			}
+			return e.Locations[i].Line < e.Locations[j].Line
+		})
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Code < entries[j].Code })
+
+	return entries
+}
+
+// derivedCode mirrors the CustomCode that cloudErrorBuilder.Build derives from
+// a status code when none is set.
+func derivedCode(statusCode int) string {
+	if statusCode < 100 || statusCode > 599 {
+		statusCode = http.StatusInternalServerError
+	}
+	return strings.ReplaceAll(http.StatusText(statusCode), " ", "")
+}
+
+func statusCode(expr ast.Expr) (int, bool) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		if e.Kind != token.INT {
+			return 0, false
+		}
+		v, err := strconv.Atoi(e.Value)
+		return v, err == nil
+	case *ast.SelectorExpr:
+		x, ok := e.X.(*ast.Ident)
+		if !ok || x.Name != "http" {
+			return 0, false
+		}
+		v, ok := httpStatusCodes[e.Sel.Name]
+		return v, ok
+	}
+	return 0, false
+}
+
+func stringLiteral(expr ast.Expr) (string, bool) {
+	lit, ok := expr.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	v, err := strconv.Unquote(lit.Value)
+	return v, err == nil
+}
+
+func containsInt(ints []int, v int) bool {
+	for _, i := range ints {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
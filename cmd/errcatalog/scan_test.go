@@ -0,0 +1,112 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	entries, err := Scan("testdata/svc", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string]*Entry{}
+	for _, e := range entries {
+		got[e.Code] = e
+	}
+
+	tests := []struct {
+		code          string
+		wantStatus    []int
+		wantLocations []Location
+	}{
+		{
+			code:       "PresetLocked",
+			wantStatus: []int{409},
+			wantLocations: []Location{
+				{File: "presets/codes.go", Line: 11, Kind: kindConstant},
+				{File: "presets/codes.go", Line: 17, Kind: kindDefinition},
+				{File: "presets/codes.go", Line: 27, Kind: kindCall},
+			},
+		},
+		{
+			code:       "PresetTooBig",
+			wantStatus: []int{413},
+			wantLocations: []Location{
+				{File: "presets/codes.go", Line: 12, Kind: kindConstant},
+				{File: "presets/codes.go", Line: 31, Kind: kindCall},
+			},
+		},
+		{
+			code:          "QuotaExceeded",
+			wantStatus:    []int{429},
+			wantLocations: []Location{{File: "presets/handler.go", Line: 11, Kind: kindDefinition}},
+		},
+		{
+			code:          "BadRequest",
+			wantStatus:    []int{400},
+			wantLocations: []Location{{File: "presets/handler.go", Line: 16, Kind: kindCall}},
+		},
+		{
+			code:          "NotFound",
+			wantStatus:    []int{404},
+			wantLocations: []Location{{File: "presets/handler.go", Line: 18, Kind: kindCall}},
+		},
+		{
+			code:          "Gone",
+			wantStatus:    []int{500},
+			wantLocations: []Location{{File: "presets/handler.go", Line: 22, Kind: kindCall}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			e, ok := got[tt.code]
+			if !ok {
+				t.Fatalf("want %s to be in the catalogue", tt.code)
+			}
+			if !reflect.DeepEqual(e.StatusCodes, tt.wantStatus) {
+				t.Errorf("want status codes %v but got %v", tt.wantStatus, e.StatusCodes)
+			}
+			if !reflect.DeepEqual(e.Locations, tt.wantLocations) {
+				t.Errorf("want locations \n%+v\nbut got \n%+v\n", tt.wantLocations, e.Locations)
+			}
+		})
+	}
+
+	if len(entries) != len(tests) {
+		t.Errorf("want %d entries but got %d", len(tests), len(entries))
+	}
+
+	t.Run("definitions should provide the message, description and retryability", func(t *testing.T) {
+		e := got["PresetLocked"]
+		if e.Message != "the preset is locked" || e.Description != "Returned when a preset is edited while locked." || e.Retryable {
+			t.Errorf("unexpected entry %+v", e)
+		}
+		if !got["QuotaExceeded"].Retryable {
+			t.Errorf("want QuotaExceeded to be retryable")
+		}
+	})
+
+	t.Run("entries should be sorted by code", func(t *testing.T) {
+		for i := 1; i < len(entries); i++ {
+			if entries[i-1].Code > entries[i].Code {
+				t.Errorf("want %s before %s", entries[i].Code, entries[i-1].Code)
+			}
+		}
+	})
+}
+
+func TestScan_IncludeTests(t *testing.T) {
+	entries, err := Scan("testdata/svc", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, e := range entries {
+		if e.Code == "I'mateapot" {
+			return
+		}
+	}
+	t.Errorf("want the code from the test file to be in the catalogue")
+}
@@ -0,0 +1,35 @@
+package presets
+
+import (
+	"net/http"
+	"time"
+
+	mterrors "github.com/music-tribe/errors"
+)
+
+const (
+	PresetLocked mterrors.CustomCode = "PresetLocked"
+	PresetTooBig mterrors.CustomCode = "PresetTooBig"
+)
+
+func init() {
+	mterrors.MustRegisterCode(
+		mterrors.CodeDefinition{
+			Code:        PresetLocked,
+			StatusCode:  http.StatusConflict,
+			Message:     "the preset is locked",
+			Description: "Returned when a preset is edited while locked.",
+		},
+	)
+}
+
+func lock() error {
+	return mterrors.NewCloudErrorFromCode(PresetLocked)
+}
+
+func upload() error {
+	return mterrors.NewCloudErrorBuilder().
+		StatusCode(413).
+		CustomCode(PresetTooBig).
+		Build(time.Now())
+}
@@ -0,0 +1,24 @@
+package presets
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/music-tribe/errors"
+)
+
+var definitions = []errors.CodeDefinition{
+	{Code: "QuotaExceeded", StatusCode: 429, Retryable: true},
+}
+
+func get(id string) error {
+	if id == "" {
+		return errors.NewCloudError(http.StatusBadRequest, "missing id")
+	}
+	return errors.NewCloudError(404, "preset missing")
+}
+
+func remove(code int) error {
+	_ = errors.NewCloudErrorBuilder().CustomCode(errors.CustomCode("Gone")).Build(time.Now())
+	return errors.NewCloudError(code, "unknown status is skipped")
+}
@@ -0,0 +1,5 @@
+package presets
+
+import "github.com/music-tribe/errors"
+
+var _ = errors.NewCloudError(418, "only found with -tests")
@@ -0,0 +1,93 @@
+package errors
+
+import (
+	"context"
+	"time"
+)
+
+type contextKey int
+
+const (
+	correlationIDKey contextKey = iota
+	serviceKey
+	userIDKey
+	tenantIDKey
+	tagsKey
+)
+
+// WithCorrelationID returns a copy of ctx carrying the correlation ID of the
+// request, to be added to every error built with that context.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey, id)
+}
+
+// CorrelationIDFromContext returns the correlation ID stored in ctx, if any.
+func CorrelationIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey).(string)
+	return id
+}
+
+// WithService returns a copy of ctx carrying the name of the service handling
+// the request. It takes precedence over ServiceName.
+func WithService(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, serviceKey, name)
+}
+
+// ServiceFromContext returns the service name stored in ctx, if any.
+func ServiceFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(serviceKey).(string)
+	return name
+}
+
+// WithUserID returns a copy of ctx carrying the ID of the user making the
+// request.
+func WithUserID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, userIDKey, id)
+}
+
+// UserIDFromContext returns the user ID stored in ctx, if any.
+func UserIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(userIDKey).(string)
+	return id
+}
+
+// WithTenantID returns a copy of ctx carrying the ID of the tenant the request
+// is made for.
+func WithTenantID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantIDKey, id)
+}
+
+// TenantIDFromContext returns the tenant ID stored in ctx, if any.
+func TenantIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(tenantIDKey).(string)
+	return id
+}
+
+// WithTags returns a copy of ctx carrying tags in addition to any it already
+// carries.
+func WithTags(ctx context.Context, tags ...string) context.Context {
+	existing := TagsFromContext(ctx)
+	all := make([]string, 0, len(existing)+len(tags))
+	all = append(all, existing...)
+	all = append(all, tags...)
+
+	return context.WithValue(ctx, tagsKey, all)
+}
+
+// TagsFromContext returns the tags stored in ctx, if any.
+func TagsFromContext(ctx context.Context) []string {
+	tags, _ := ctx.Value(tagsKey).([]string)
+	return tags
+}
+
+// NewCloudErrorCtx is NewCloudError with the correlation ID, service, user ID,
+// tenant ID and tags taken from ctx.
+func NewCloudErrorCtx(ctx context.Context, statusCode int, message any, options ...CloudErrorOption) *CloudError {
+	se := NewCloudErrorBuilder().
+		StatusCode(statusCode).
+		Error(message).
+		Context(ctx).
+		Build(time.Now().UTC(), options...)
+
+	return se
+}
@@ -0,0 +1,87 @@
+package errors
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testContext() context.Context {
+	ctx := context.Background()
+	ctx = WithCorrelationID(ctx, "corr-1")
+	ctx = WithService(ctx, "svc-presets")
+	ctx = WithUserID(ctx, "user-1")
+	ctx = WithTenantID(ctx, "tenant-1")
+	ctx = WithTags(ctx, "presets")
+	return WithTags(ctx, "upload")
+}
+
+func TestNewCloudErrorCtx(t *testing.T) {
+	When("the context carries request metadata", t,
+		Then("it should be copied to the error", func(t *testing.T) {
+			got := NewCloudErrorCtx(testContext(), 404, "preset missing")
+
+			if got.CorrelationID != "corr-1" || got.ErrorLocation.Service != "svc-presets" ||
+				got.UserID != "user-1" || got.TenantID != "tenant-1" {
+				t.Errorf("unexpected error %+v", got)
+			}
+			if want := []string{"presets", "upload"}; !reflect.DeepEqual(got.Tags, want) {
+				t.Errorf("expected tags %v but got %v", want, got.Tags)
+			}
+		}),
+		And("the location should still be the caller", func(t *testing.T) {
+			got := NewCloudErrorCtx(testContext(), 404, "preset missing")
+			if got.ErrorLocation.Function != "TestNewCloudErrorCtx.func2" {
+				t.Errorf("expected the location to be this test but got %s", got.ErrorLocation.Function)
+			}
+		}),
+		And("options should take precedence", func(t *testing.T) {
+			got := NewCloudErrorCtx(testContext(), 404, "preset missing", SetCorrelationIDOption("mine"))
+			if got.CorrelationID != "mine" {
+				t.Errorf("expected the option's correlation ID but got %s", got.CorrelationID)
+			}
+		}),
+	)
+
+	When("the context is empty", t,
+		Then("the error should be the same as one from NewCloudError", func(t *testing.T) {
+			got := NewCloudErrorCtx(context.Background(), 404, "preset missing")
+			if got.CorrelationID != "" || got.ErrorLocation.Service != ServiceName() || got.Tags != nil {
+				t.Errorf("unexpected error %+v", got)
+			}
+		}),
+	)
+}
+
+func Test_cloudErrorBuilder_Context(t *testing.T) {
+	When("values are already set on the builder", t,
+		Then("they should be kept and the context's tags added", func(t *testing.T) {
+			got := NewCloudErrorBuilder().
+				CorrelationID("mine").
+				Tags("first").
+				Context(testContext()).
+				Build(time.Now().UTC())
+
+			if got.CorrelationID != "mine" || got.UserID != "user-1" {
+				t.Errorf("unexpected error %+v", got)
+			}
+			if want := []string{"first", "presets", "upload"}; !reflect.DeepEqual(got.Tags, want) {
+				t.Errorf("expected tags %v but got %v", want, got.Tags)
+			}
+		}),
+	)
+}
+
+func TestWithTags(t *testing.T) {
+	When("tags are added to a context that is shared", t,
+		Then("the parent context should not change", func(t *testing.T) {
+			parent := WithTags(context.Background(), "a")
+			_ = WithTags(parent, "b")
+
+			if got := TagsFromContext(parent); !reflect.DeepEqual(got, []string{"a"}) {
+				t.Errorf("expected [a] but got %v", got)
+			}
+		}),
+	)
+}
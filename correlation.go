@@ -0,0 +1,170 @@
+package errors
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/music-tribe/uuid"
+)
+
+// Header names that can carry the correlation ID of a request.
+const (
+	HeaderRequestID     = "X-Request-ID"
+	HeaderCorrelationID = "X-Correlation-ID"
+	// HeaderTraceparent is the W3C Trace Context header. The trace ID in it is
+	// used as the correlation ID.
+	HeaderTraceparent = "traceparent"
+)
+
+var (
+	correlationMu      sync.RWMutex
+	correlationHeaders = []string{HeaderRequestID}
+)
+
+// SetCorrelationHeaders sets the headers the correlation ID is read from, in
+// order of preference, and written to. It defaults to X-Request-ID.
+func SetCorrelationHeaders(names ...string) {
+	headers := make([]string, len(names))
+	copy(headers, names)
+
+	correlationMu.Lock()
+	defer correlationMu.Unlock()
+	correlationHeaders = headers
+}
+
+// CorrelationHeaders returns the headers set with SetCorrelationHeaders.
+func CorrelationHeaders() []string {
+	correlationMu.RLock()
+	defer correlationMu.RUnlock()
+
+	headers := make([]string, len(correlationHeaders))
+	copy(headers, correlationHeaders)
+	return headers
+}
+
+// NewCorrelationID returns a new random correlation ID.
+func NewCorrelationID() string {
+	return uuid.New().String()
+}
+
+// CorrelationIDFromHeader returns the correlation ID from the first of the
+// correlation headers that is set in h.
+func CorrelationIDFromHeader(h http.Header) string {
+	return CorrelationIDFromHeaders(h, CorrelationHeaders()...)
+}
+
+// CorrelationIDFromHeaders is like CorrelationIDFromHeader, but reads the
+// named headers instead of the ones set with SetCorrelationHeaders.
+func CorrelationIDFromHeaders(h http.Header, names ...string) string {
+	for _, name := range names {
+		v := h.Get(name)
+		if v == "" {
+			continue
+		}
+
+		if isTraceparent(name) {
+			if id, ok := traceIDFromTraceparent(v); ok {
+				return id
+			}
+			continue
+		}
+
+		return v
+	}
+
+	return ""
+}
+
+// SetCorrelationIDHeader sets id on every correlation header of h. The
+// traceparent header is only set if id can be used as a trace ID, i.e. it is
+// 32 hex digits once any dashes are removed, as is the case for the IDs
+// returned by NewCorrelationID.
+func SetCorrelationIDHeader(h http.Header, id string) {
+	SetCorrelationIDHeaders(h, id, CorrelationHeaders()...)
+}
+
+// SetCorrelationIDHeaders is like SetCorrelationIDHeader, but sets the named
+// headers instead of the ones set with SetCorrelationHeaders.
+func SetCorrelationIDHeaders(h http.Header, id string, names ...string) {
+	for _, name := range names {
+		if isTraceparent(name) {
+			if tp, ok := traceparentFor(id); ok {
+				h.Set(name, tp)
+			}
+			continue
+		}
+
+		h.Set(name, id)
+	}
+}
+
+// CorrelationTransport is an http.RoundTripper that forwards the correlation
+// ID stored in each request's context, see WithCorrelationID, in the
+// correlation headers. Requests that already carry a correlation ID are sent
+// as they are.
+type CorrelationTransport struct {
+	// Base is the RoundTripper used to make requests. If nil,
+	// http.DefaultTransport is used.
+	Base http.RoundTripper
+}
+
+func (t *CorrelationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := CorrelationIDFromContext(req.Context())
+	if id == "" || CorrelationIDFromHeader(req.Header) != "" {
+		return t.base().RoundTrip(req)
+	}
+
+	// a RoundTripper must not modify the request it was given
+	req = req.Clone(req.Context())
+	SetCorrelationIDHeader(req.Header, id)
+
+	return t.base().RoundTrip(req)
+}
+
+func (t *CorrelationTransport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+func isTraceparent(name string) bool {
+	return strings.EqualFold(name, HeaderTraceparent)
+}
+
+// traceIDFromTraceparent returns the trace ID of a traceparent header value,
+// version-traceid-parentid-flags.
+func traceIDFromTraceparent(v string) (string, bool) {
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 {
+		return "", false
+	}
+
+	return parts[1], isTraceID(parts[1])
+}
+
+// traceparentFor returns a traceparent header value with id as the trace ID
+// and a new random parent ID.
+func traceparentFor(id string) (string, bool) {
+	traceID := strings.ToLower(strings.ReplaceAll(id, "-", ""))
+	if !isTraceID(traceID) {
+		return "", false
+	}
+
+	parentID := strings.ReplaceAll(NewCorrelationID(), "-", "")[:16]
+	return "00-" + traceID + "-" + parentID + "-01", true
+}
+
+func isTraceID(s string) bool {
+	if len(s) != 32 || s == strings.Repeat("0", 32) {
+		return false
+	}
+
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package errors
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+func TestCorrelationIDFromHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers []string
+		header  http.Header
+		want    string
+	}{
+		{
+			name:    "when the default header is set",
+			headers: []string{HeaderRequestID},
+			header:  http.Header{"X-Request-Id": {"req-1"}},
+			want:    "req-1",
+		},
+		{
+			name:    "when several headers are configured the first that is set wins",
+			headers: []string{HeaderCorrelationID, HeaderRequestID},
+			header:  http.Header{"X-Request-Id": {"req-1"}, "X-Correlation-Id": {"corr-1"}},
+			want:    "corr-1",
+		},
+		{
+			name:    "when the traceparent header is used the trace ID is the correlation ID",
+			headers: []string{HeaderTraceparent},
+			header:  http.Header{"Traceparent": {"00-" + testTraceID + "-00f067aa0ba902b7-01"}},
+			want:    testTraceID,
+		},
+		{
+			name:    "when the traceparent header is malformed the next header is used",
+			headers: []string{HeaderTraceparent, HeaderRequestID},
+			header:  http.Header{"Traceparent": {"garbage"}, "X-Request-Id": {"req-1"}},
+			want:    "req-1",
+		},
+		{
+			name:    "when no header is set",
+			headers: []string{HeaderRequestID},
+			header:  http.Header{},
+			want:    "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetCorrelationHeaders(tt.headers...)
+			defer SetCorrelationHeaders(HeaderRequestID)
+
+			if got := CorrelationIDFromHeader(tt.header); got != tt.want {
+				t.Errorf("CorrelationIDFromHeader() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetCorrelationIDHeader(t *testing.T) {
+	SetCorrelationHeaders(HeaderRequestID, HeaderTraceparent)
+	defer SetCorrelationHeaders(HeaderRequestID)
+
+	When("the ID is a uuid", t,
+		Then("every header should be set", func(t *testing.T) {
+			id := NewCorrelationID()
+			h := http.Header{}
+			SetCorrelationIDHeader(h, id)
+
+			if h.Get(HeaderRequestID) != id {
+				t.Errorf("expected %s but got %s", id, h.Get(HeaderRequestID))
+			}
+
+			traceID := strings.ReplaceAll(id, "-", "")
+			if tp := h.Get(HeaderTraceparent); !strings.HasPrefix(tp, "00-"+traceID+"-") || len(tp) != 55 {
+				t.Errorf("unexpected traceparent %s", tp)
+			}
+			if got := CorrelationIDFromHeader(http.Header{"Traceparent": h.Values(HeaderTraceparent)}); got != traceID {
+				t.Errorf("expected the trace ID to read back as %s but got %s", traceID, got)
+			}
+		}),
+	)
+
+	When("the ID cannot be used as a trace ID", t,
+		Then("the traceparent header should not be set", func(t *testing.T) {
+			h := http.Header{}
+			SetCorrelationIDHeader(h, "not-a-trace-id")
+
+			if h.Get(HeaderRequestID) != "not-a-trace-id" || h.Get(HeaderTraceparent) != "" {
+				t.Errorf("unexpected headers %v", h)
+			}
+		}),
+	)
+}
+
+func TestCorrelationTransport(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(HeaderRequestID)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &CorrelationTransport{}}
+
+	When("the request context has a correlation ID", t,
+		Then("it should be forwarded", func(t *testing.T) {
+			req, _ := http.NewRequestWithContext(WithCorrelationID(context.Background(), "corr-1"), "GET", srv.URL, nil)
+			if _, err := client.Do(req); err != nil {
+				t.Fatal(err)
+			}
+
+			if got != "corr-1" {
+				t.Errorf("expected corr-1 but got %q", got)
+			}
+			if req.Header.Get(HeaderRequestID) != "" {
+				t.Errorf("expected the original request to be left alone")
+			}
+		}),
+	)
+
+	When("the request already has a correlation header", t,
+		Then("it should be kept", func(t *testing.T) {
+			req, _ := http.NewRequestWithContext(WithCorrelationID(context.Background(), "corr-1"), "GET", srv.URL, nil)
+			req.Header.Set(HeaderRequestID, "mine")
+			if _, err := client.Do(req); err != nil {
+				t.Fatal(err)
+			}
+
+			if got != "mine" {
+				t.Errorf("expected mine but got %q", got)
+			}
+		}),
+	)
+}
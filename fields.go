@@ -0,0 +1,95 @@
+package errors
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+// FieldError is a problem with a single field of a request, such as a
+// validation rule it broke.
+type FieldError struct {
+	// Field is the path of the field, e.g. "address.city" or "items[2].sku".
+	Field string `json:"field"`
+	// Rule is the name of the rule that failed, e.g. "required" or "max".
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
+	// RejectedValue is the value that was rejected, subject to the
+	// RejectedValuePolicy.
+	RejectedValue any `json:"rejected_value,omitempty"`
+}
+
+// FieldErrors are the problems with the fields of a request.
+type FieldErrors []FieldError
+
+// Error lists the fields and their messages.
+func (fe FieldErrors) Error() string {
+	msgs := make([]string, len(fe))
+	for i, f := range fe {
+		msgs[i] = f.Field + ": " + f.Message
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// RejectedValuePolicy sets whether rejected values are kept in FieldErrors.
+// Values may well be personal data or secrets, so they are omitted by
+// default.
+type RejectedValuePolicy int32
+
+const (
+	OmitRejectedValues RejectedValuePolicy = iota
+	IncludeRejectedValues
+	// MaskRejectedValues replaces rejected values with MaskedValue, so that
+	// clients can tell there was a value without seeing it.
+	MaskRejectedValues
+)
+
+// MaskedValue replaces rejected values under MaskRejectedValues.
+const MaskedValue = "[masked]"
+
+var rejectedValuePolicy atomic.Int32
+
+// SetRejectedValuePolicy sets the policy applied by NewFieldError for every
+// error in the process.
+func SetRejectedValuePolicy(policy RejectedValuePolicy) {
+	rejectedValuePolicy.Store(int32(policy))
+}
+
+// NewFieldError returns a FieldError, keeping value according to the
+// RejectedValuePolicy.
+func NewFieldError(field, rule, message string, value any) FieldError {
+	fe := FieldError{Field: field, Rule: rule, Message: message}
+
+	switch RejectedValuePolicy(rejectedValuePolicy.Load()) {
+	case IncludeRejectedValues:
+		fe.RejectedValue = value
+	case MaskRejectedValues:
+		if value != nil {
+			fe.RejectedValue = MaskedValue
+		}
+	}
+
+	return fe
+}
+
+// FieldError adds a problem with a field of the request, see NewFieldError.
+// Errors with field errors default to 400 Bad Request.
+func (s *cloudErrorBuilder) FieldError(field, rule, message string, value any) *cloudErrorBuilder {
+	s.err.FieldErrors = append(s.err.FieldErrors, NewFieldError(field, rule, message, value))
+	return s
+}
+
+// FieldErrors adds problems with fields of the request. Errors with field
+// errors default to 400 Bad Request.
+func (s *cloudErrorBuilder) FieldErrors(fes ...FieldError) *cloudErrorBuilder {
+	s.err.FieldErrors = append(s.err.FieldErrors, fes...)
+	return s
+}
+
+// FieldErrorsOption adds problems with fields of the request to an error
+// built by NewCloudError.
+func FieldErrorsOption(fes ...FieldError) CloudErrorOption {
+	return func(se *CloudError) {
+		se.FieldErrors = append(se.FieldErrors, fes...)
+	}
+}
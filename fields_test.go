@@ -0,0 +1,99 @@
+package errors
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewFieldError(t *testing.T) {
+	t.Cleanup(func() { SetRejectedValuePolicy(OmitRejectedValues) })
+
+	When("the policy is the default", t,
+		Then("the rejected value should be omitted", func(t *testing.T) {
+			got := NewFieldError("name", "max", "must be at most 10", "a very long name")
+			want := FieldError{Field: "name", Rule: "max", Message: "must be at most 10"}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("expected %+v but got %+v", want, got)
+			}
+		}),
+	)
+
+	When("the policy includes rejected values", t,
+		Then("the rejected value should be kept", func(t *testing.T) {
+			SetRejectedValuePolicy(IncludeRejectedValues)
+			if got := NewFieldError("age", "min", "must be at least 18", 12); got.RejectedValue != 12 {
+				t.Errorf("expected the rejected value but got %v", got.RejectedValue)
+			}
+		}),
+	)
+
+	When("the policy masks rejected values", t,
+		Then("the rejected value should be masked", func(t *testing.T) {
+			SetRejectedValuePolicy(MaskRejectedValues)
+			if got := NewFieldError("email", "email", "must be an email address", "bob@"); got.RejectedValue != MaskedValue {
+				t.Errorf("expected the rejected value to be masked but got %v", got.RejectedValue)
+			}
+		}),
+		And("a missing value should stay missing", func(t *testing.T) {
+			SetRejectedValuePolicy(MaskRejectedValues)
+			if got := NewFieldError("email", "required", "is required", nil); got.RejectedValue != nil {
+				t.Errorf("expected no rejected value but got %v", got.RejectedValue)
+			}
+		}),
+	)
+}
+
+func TestCloudErrorBuilder_FieldErrors(t *testing.T) {
+	ce := NewCloudErrorBuilder().
+		Message("invalid preset").
+		FieldError("name", "required", "is required", nil).
+		FieldErrors(FieldError{Field: "tags[0]", Rule: "max", Message: "must be at most 32"}).
+		Build(time.Now().UTC())
+
+	When("field errors are added without a status code", t,
+		Then("the error should be a 400", func(t *testing.T) {
+			if ce.StatusCode != 400 || ce.Status != "Bad Request" {
+				t.Errorf("expected a 400 but got %d %s", ce.StatusCode, ce.Status)
+			}
+		}),
+		And("the field errors should be kept in order", func(t *testing.T) {
+			if len(ce.FieldErrors) != 2 || ce.FieldErrors[0].Field != "name" || ce.FieldErrors[1].Field != "tags[0]" {
+				t.Errorf("unexpected field errors %+v", ce.FieldErrors)
+			}
+		}),
+		And("they should be in the JSON", func(t *testing.T) {
+			byt, _ := json.Marshal(ce)
+			if !strings.Contains(string(byt), `"field_errors":[{"field":"name","rule":"required","message":"is required"}`) {
+				t.Errorf("expected field errors in %s", byt)
+			}
+		}),
+		And("they should be in the problem details errors", func(t *testing.T) {
+			byt, _ := json.Marshal(ce.Problem())
+			if !strings.Contains(string(byt), `"errors":[{"field":"name"`) {
+				t.Errorf("expected field errors in %s", byt)
+			}
+			if got := ce.Problem().CloudError(); !reflect.DeepEqual(got.FieldErrors, ce.FieldErrors) {
+				t.Errorf("expected field errors to survive a round trip but got %+v", got.FieldErrors)
+			}
+		}),
+	)
+
+	When("a status code is set", t,
+		Then("it should be kept", func(t *testing.T) {
+			got := NewCloudErrorBuilder().StatusCode(422).FieldError("name", "required", "is required", nil).Build(time.Now().UTC())
+			if got.StatusCode != 422 {
+				t.Errorf("expected a 422 but got %d", got.StatusCode)
+			}
+		}),
+	)
+}
+
+func TestFieldErrors_Error(t *testing.T) {
+	fes := FieldErrors{{Field: "name", Message: "is required"}, {Field: "age", Message: "must be at least 18"}}
+	if got, want := fes.Error(), "name: is required; age: must be at least 18"; got != want {
+		t.Errorf("expected %q but got %q", want, got)
+	}
+}
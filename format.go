@@ -0,0 +1,72 @@
+package errors
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// ErrorFormat selects the string returned by CloudError.Error.
+type ErrorFormat int32
+
+const (
+	// FormatText is a single line such as
+	// "404 NotFound: user missing (sql: no rows in result set) [corr=4f1c...]",
+	// with the internal message in brackets if it differs. It is the default.
+	FormatText ErrorFormat = iota
+	// FormatJSON is the error marshalled as compact JSON.
+	FormatJSON
+	// FormatIndentedJSON is the error marshalled as indented JSON.
+	FormatIndentedJSON
+)
+
+var errorFormat atomic.Int32
+
+// SetErrorFormat sets the format of the string returned by CloudError.Error
+// for every error in the process.
+func SetErrorFormat(format ErrorFormat) {
+	errorFormat.Store(int32(format))
+}
+
+func (se *CloudError) text() string {
+	var b strings.Builder
+	b.Grow(len(se.Message) + len(se.InternalMessage) + len(se.CustomCode) + len(se.CorrelationID) + 16)
+
+	b.WriteString(strconv.Itoa(se.StatusCode))
+	if se.CustomCode != "" {
+		b.WriteByte(' ')
+		b.WriteString(string(se.CustomCode))
+	}
+	b.WriteString(": ")
+	b.WriteString(se.Message)
+	if se.InternalMessage != "" && se.InternalMessage != se.Message {
+		b.WriteString(" (")
+		b.WriteString(se.InternalMessage)
+		b.WriteByte(')')
+	}
+	if se.CorrelationID != "" {
+		b.WriteString(" [corr=")
+		b.WriteString(se.CorrelationID)
+		b.WriteByte(']')
+	}
+
+	return b.String()
+}
+
+func (se *CloudError) json(indent bool) string {
+	var (
+		byt []byte
+		err error
+	)
+	if indent {
+		byt, err = json.MarshalIndent(se, "", "  ")
+	} else {
+		byt, err = json.Marshal(se)
+	}
+	if err != nil {
+		return se.text()
+	}
+
+	return string(byt)
+}
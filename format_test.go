@@ -0,0 +1,93 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCloudError_Error(t *testing.T) {
+	ce := NewCloudError(404, "user missing", SetCorrelationIDOption("corr-1"))
+
+	tests := []struct {
+		name   string
+		format ErrorFormat
+		check  func(t *testing.T, got string)
+	}{
+		{
+			name:   "text",
+			format: FormatText,
+			check: func(t *testing.T, got string) {
+				if want := "404 NotFound: user missing [corr=corr-1]"; got != want {
+					t.Errorf("expected %q but got %q", want, got)
+				}
+			},
+		},
+		{
+			name:   "compact json",
+			format: FormatJSON,
+			check: func(t *testing.T, got string) {
+				if strings.Contains(got, "\n") || !json.Valid([]byte(got)) {
+					t.Errorf("expected a single line of JSON but got %q", got)
+				}
+			},
+		},
+		{
+			name:   "indented json",
+			format: FormatIndentedJSON,
+			check: func(t *testing.T, got string) {
+				if !strings.Contains(got, "\n  \"status_code\": 404") || !json.Valid([]byte(got)) {
+					t.Errorf("expected indented JSON but got %q", got)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetErrorFormat(tt.format)
+			defer SetErrorFormat(FormatText)
+
+			tt.check(t, ce.Error())
+		})
+	}
+
+	When("the error has no custom code or correlation ID", t,
+		Then("they should be left out of the text", func(t *testing.T) {
+			got := (&CloudError{StatusCode: 418, Message: "short and stout"}).Error()
+			if want := "418: short and stout"; got != want {
+				t.Errorf("expected %q but got %q", want, got)
+			}
+		}),
+	)
+}
+
+func TestCloudError_FormatVerbs(t *testing.T) {
+	ce := NewCloudError(404, "user missing", SetCorrelationIDOption("corr-1"))
+
+	When("formatted with %+v", t,
+		Then("every field should be printed", func(t *testing.T) {
+			got := fmt.Sprintf("%+v", ce)
+			if !strings.Contains(got, `"correlation_id": "corr-1"`) || !strings.Contains(got, `"location": {`) {
+				t.Errorf("expected full detail but got %s", got)
+			}
+		}),
+	)
+
+	When("formatted with %#v", t,
+		Then("the Go syntax representation should be printed", func(t *testing.T) {
+			if got := fmt.Sprintf("%#v", ce); !strings.HasPrefix(got, "&errors.CloudError{StatusCode:404") {
+				t.Errorf("unexpected %%#v output %s", got)
+			}
+		}),
+	)
+
+	When("formatted with %q", t,
+		Then("the quoted error should be printed", func(t *testing.T) {
+			if got, want := fmt.Sprintf("%q", ce), `"404 NotFound: user missing [corr=corr-1]"`; got != want {
+				t.Errorf("expected %s but got %s", want, got)
+			}
+		}),
+	)
+}
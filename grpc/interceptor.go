@@ -0,0 +1,75 @@
+package grpc
+
+import (
+	"context"
+	"io"
+
+	"google.golang.org/grpc"
+)
+
+// UnaryServerInterceptor converts CloudErrors returned by unary handlers into
+// gRPC statuses.
+func UnaryServerInterceptor(opts ...Option) grpc.UnaryServerInterceptor {
+	o := newOptions(opts)
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, o.toStatusError(err)
+		}
+		return resp, nil
+	}
+}
+
+// StreamServerInterceptor converts CloudErrors returned by stream handlers into
+// gRPC statuses.
+func StreamServerInterceptor(opts ...Option) grpc.StreamServerInterceptor {
+	o := newOptions(opts)
+
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := handler(srv, ss); err != nil {
+			return o.toStatusError(err)
+		}
+		return nil
+	}
+}
+
+// UnaryClientInterceptor converts status errors returned by unary calls back
+// into CloudErrors.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return fromStatusError(invoker(ctx, method, req, reply, cc, opts...))
+	}
+}
+
+// StreamClientInterceptor converts status errors returned by streaming calls,
+// including those surfaced by SendMsg and RecvMsg, back into CloudErrors.
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, fromStatusError(err)
+		}
+		return &clientStream{cs}, nil
+	}
+}
+
+type clientStream struct {
+	grpc.ClientStream
+}
+
+func (cs *clientStream) SendMsg(m any) error {
+	err := cs.ClientStream.SendMsg(m)
+	if err == io.EOF {
+		return err
+	}
+	return fromStatusError(err)
+}
+
+func (cs *clientStream) RecvMsg(m any) error {
+	err := cs.ClientStream.RecvMsg(m)
+	if err == io.EOF {
+		return err
+	}
+	return fromStatusError(err)
+}
@@ -0,0 +1,211 @@
+package grpc
+
+import (
+	"context"
+	errs "errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/music-tribe/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testCorrelationID = "5f1aa5d0-bdb1-4cd7-a807-6d673f49f871"
+
+type testHealthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	err error
+}
+
+func (s *testHealthServer) Check(context.Context, *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
+}
+
+func (s *testHealthServer) Watch(_ *grpc_health_v1.HealthCheckRequest, ss grpc_health_v1.Health_WatchServer) error {
+	if err := ss.Send(&grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}); err != nil {
+		return err
+	}
+	return s.err
+}
+
+func newTestClient(t *testing.T, srvErr error, clientOpts ...grpc.DialOption) grpc_health_v1.HealthClient {
+	t.Helper()
+
+	return newTestClientWithOptions(t, srvErr, nil, clientOpts...)
+}
+
+func newTestClientWithOptions(t *testing.T, srvErr error, srvOpts []Option, clientOpts ...grpc.DialOption) grpc_health_v1.HealthClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(UnaryServerInterceptor(srvOpts...)),
+		grpc.StreamInterceptor(StreamServerInterceptor(srvOpts...)),
+	)
+	grpc_health_v1.RegisterHealthServer(srv, &testHealthServer{err: srvErr})
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	opts := append([]grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, clientOpts...)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return grpc_health_v1.NewHealthClient(conn)
+}
+
+func withCloudErrorClient() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(StreamClientInterceptor()),
+	}
+}
+
+func testCloudError() *errors.CloudError {
+	return errors.NewCloudError(403, "do one!", func(ce *errors.CloudError) {
+		ce.CorrelationID = testCorrelationID
+		ce.CustomCode = "PresetLocked"
+		ce.Source = "svc-presets"
+		ce.Tags = []string{"preset"}
+	})
+}
+
+func assertCloudError(t *testing.T, err error) {
+	t.Helper()
+
+	var ce *errors.CloudError
+	if !errs.As(err, &ce) {
+		t.Fatalf("want a *CloudError but got %T: %v", err, err)
+	}
+	if ce.StatusCode != 403 || ce.CustomCode != "PresetLocked" || ce.CorrelationID != testCorrelationID ||
+		ce.Source != "svc-presets" || ce.Message != "do one!" || len(ce.Tags) != 1 || ce.Tags[0] != "preset" {
+		t.Errorf("unexpected cloud error %+v", ce)
+	}
+}
+
+func TestUnaryInterceptors(t *testing.T) {
+	t.Run("when the handler returns a cloud error the server should send a status", func(t *testing.T) {
+		client := newTestClient(t, testCloudError())
+
+		_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		if got := status.Code(err); got != codes.PermissionDenied {
+			t.Errorf("want code %s but got %s", codes.PermissionDenied, got)
+		}
+	})
+
+	t.Run("when the handler returns a wrapped cloud error it should still be converted", func(t *testing.T) {
+		client := newTestClient(t, fmt.Errorf("check: %w", testCloudError()), withCloudErrorClient()...)
+
+		_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		assertCloudError(t, err)
+	})
+
+	t.Run("when the client interceptor is installed it should return the cloud error", func(t *testing.T) {
+		client := newTestClient(t, testCloudError(), withCloudErrorClient()...)
+
+		_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		assertCloudError(t, err)
+	})
+
+	t.Run("when the handler returns a plain status the client should synthesize a cloud error", func(t *testing.T) {
+		client := newTestClient(t, status.Error(codes.NotFound, "no such service"), withCloudErrorClient()...)
+
+		_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+
+		var ce *errors.CloudError
+		if !errs.As(err, &ce) {
+			t.Fatalf("want a *CloudError but got %T: %v", err, err)
+		}
+		if ce.StatusCode != 404 || ce.CustomCode != errors.NotFound || ce.Message != "no such service" {
+			t.Errorf("unexpected cloud error %+v", ce)
+		}
+	})
+
+	t.Run("when the client interceptor converts a status the gRPC code should still be reachable", func(t *testing.T) {
+		tests := []struct {
+			srvErr error
+			code   codes.Code
+		}{
+			{testCloudError(), codes.PermissionDenied},
+			{status.Error(codes.Unavailable, "connection reset"), codes.Unavailable},
+		}
+		for _, tt := range tests {
+			client := newTestClient(t, tt.srvErr, withCloudErrorClient()...)
+
+			_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+
+			var ce *errors.CloudError
+			if !errs.As(err, &ce) {
+				t.Fatalf("want a *CloudError but got %T: %v", err, err)
+			}
+			if got := status.Code(err); got != tt.code {
+				t.Errorf("want code %s but got %s", tt.code, got)
+			}
+		}
+	})
+
+	t.Run("when the call succeeds no error should be returned", func(t *testing.T) {
+		client := newTestClient(t, nil, withCloudErrorClient()...)
+
+		if _, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{}); err != nil {
+			t.Errorf("want no error but got %v", err)
+		}
+	})
+
+	t.Run("when the handler returns an internal message it should only be sent if the server opts in", func(t *testing.T) {
+		srvErr := errors.NewCloudErrorBuilder().
+			StatusCode(500).
+			InternalMessage("dial postgres://svc:hunter2@db failed").
+			Build(time.Now().UTC())
+
+		for _, send := range []bool{false, true} {
+			client := newTestClientWithOptions(t, srvErr, []Option{WithInternalMessage(send)}, withCloudErrorClient()...)
+
+			_, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+
+			var ce *errors.CloudError
+			if !errs.As(err, &ce) {
+				t.Fatalf("want a *CloudError but got %T: %v", err, err)
+			}
+			if got := ce.InternalMessage != ""; got != send {
+				t.Errorf("WithInternalMessage(%v): want internal message sent to be %v but got %q", send, send, ce.InternalMessage)
+			}
+		}
+	})
+}
+
+func TestStreamInterceptors(t *testing.T) {
+	t.Run("when the stream handler returns a cloud error the client should receive it", func(t *testing.T) {
+		client := newTestClient(t, testCloudError(), withCloudErrorClient()...)
+
+		stream, err := client.Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := stream.Recv(); err != nil {
+			t.Fatalf("want the first message to be received but got %v", err)
+		}
+
+		_, err = stream.Recv()
+		assertCloudError(t, err)
+	})
+}
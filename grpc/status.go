@@ -0,0 +1,242 @@
+// Package grpc carries CloudErrors across gRPC calls by converting them to and
+// from gRPC statuses.
+package grpc
+
+import (
+	"encoding/json"
+	errs "errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/music-tribe/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+const (
+	metadataStatusCode = "status_code"
+	metadataTags       = "tags"
+	metadataInternal   = "internal_message"
+)
+
+// Option configures how CloudErrors are converted into gRPC statuses by
+// ToStatus and the server interceptors.
+type Option func(*options)
+
+type options struct {
+	internalMessage bool
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithInternalMessage sets whether the InternalMessage of CloudErrors is sent
+// to clients. It is off by default, as the internal message is meant for the
+// service's own logs and often holds driver or SQL errors; only turn it on
+// between trusted services.
+func WithInternalMessage(send bool) Option {
+	return func(o *options) {
+		o.internalMessage = send
+	}
+}
+
+// ToStatus converts a CloudError into a gRPC status. The CustomCode and Source
+// are attached as an ErrorInfo detail (reason and domain), the CorrelationID as
+// a RequestInfo detail, and the HTTP status code and tags as ErrorInfo metadata
+// so that FromStatus can rebuild the original error. Field errors are attached
+// as a BadRequest detail. The internal message is only attached, as ErrorInfo
+// metadata, with WithInternalMessage.
+func ToStatus(ce *errors.CloudError, opts ...Option) *status.Status {
+	return newOptions(opts).toStatus(ce)
+}
+
+func (o *options) toStatus(ce *errors.CloudError) *status.Status {
+	st := status.New(CodeFromHTTPStatus(ce.StatusCode), ce.Message)
+
+	info := &errdetails.ErrorInfo{
+		Reason: string(ce.CustomCode),
+		Domain: ce.Source,
+		Metadata: map[string]string{
+			metadataStatusCode: strconv.Itoa(ce.StatusCode),
+		},
+	}
+	if len(ce.Tags) > 0 {
+		// tags are free text, so they are sent as a JSON array rather than
+		// joined on a separator they may contain
+		if tags, err := json.Marshal(ce.Tags); err == nil {
+			info.Metadata[metadataTags] = string(tags)
+		}
+	}
+	if o.internalMessage && ce.InternalMessage != "" {
+		info.Metadata[metadataInternal] = ce.InternalMessage
+	}
+
+	details := []protoadapt.MessageV1{info, &errdetails.RequestInfo{RequestId: ce.CorrelationID}}
+	if len(ce.FieldErrors) > 0 {
+		br := &errdetails.BadRequest{}
+		for _, fe := range ce.FieldErrors {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       fe.Field,
+				Description: fe.Message,
+			})
+		}
+		details = append(details, br)
+	}
+
+	withDetails, err := st.WithDetails(details...)
+	if err != nil {
+		return st
+	}
+
+	return withDetails
+}
+
+// FromStatus converts a gRPC status back into a CloudError. Statuses that were
+// not produced by ToStatus are synthesized into a CloudError whose status code
+// is mapped from the gRPC code. The status error is kept as the CloudError's
+// internal error, so status.Code and status.FromError still see the original
+// status. It returns nil for an OK status.
+func FromStatus(st *status.Status) *errors.CloudError {
+	if st == nil || st.Code() == codes.OK {
+		return nil
+	}
+
+	builder := errors.NewCloudErrorBuilder().
+		StatusCode(HTTPStatusFromCode(st.Code())).
+		Message(st.Message())
+
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			builder.CustomCode(errors.CustomCode(d.Reason)).Source(d.Domain)
+			if sc, err := strconv.Atoi(d.Metadata[metadataStatusCode]); err == nil {
+				builder.StatusCode(sc)
+			}
+			var tags []string
+			if err := json.Unmarshal([]byte(d.Metadata[metadataTags]), &tags); err == nil && len(tags) > 0 {
+				builder.Tags(tags...)
+			}
+			if internal := d.Metadata[metadataInternal]; internal != "" {
+				builder.InternalMessage(internal)
+			}
+		case *errdetails.RequestInfo:
+			builder.CorrelationID(d.RequestId)
+		case *errdetails.BadRequest:
+			for _, v := range d.FieldViolations {
+				builder.FieldErrors(errors.FieldError{Field: v.Field, Message: v.Description})
+			}
+		}
+	}
+
+	ce := builder.Build(time.Now().UTC())
+	// set directly, as the builder would replace the internal message too
+	ce.InternalError = st.Err()
+
+	return ce
+}
+
+// toStatusError converts err into a status error if it is, or wraps, a
+// CloudError. Any other error is returned unchanged.
+func (o *options) toStatusError(err error) error {
+	ce := &errors.CloudError{}
+	if !errs.As(err, &ce) {
+		return err
+	}
+
+	return o.toStatus(ce).Err()
+}
+
+// fromStatusError converts a status error into a CloudError. Errors that do not
+// carry a gRPC status are returned unchanged.
+func fromStatusError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	if ce := FromStatus(st); ce != nil {
+		return ce
+	}
+	return err
+}
+
+// CodeFromHTTPStatus maps an HTTP status code onto the closest gRPC code.
+// Status codes below 400 are not errors, but a CloudError carrying one still
+// has to fail the call, so they map to Unknown rather than OK.
+func CodeFromHTTPStatus(statusCode int) codes.Code {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case 499:
+		return codes.Canceled
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+
+	switch {
+	case statusCode >= 400 && statusCode < 500:
+		return codes.FailedPrecondition
+	case statusCode >= 500:
+		return codes.Internal
+	}
+	return codes.Unknown
+}
+
+// HTTPStatusFromCode maps a gRPC code onto the closest HTTP status code.
+func HTTPStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
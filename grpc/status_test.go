@@ -0,0 +1,147 @@
+package grpc
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/music-tribe/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestToStatus(t *testing.T) {
+	ce := errors.NewCloudErrorBuilder().
+		StatusCode(404).
+		CustomCode("PresetNotFound").
+		CorrelationID("5f1aa5d0-bdb1-4cd7-a807-6d673f49f871").
+		Source("svc-presets").
+		Tags("preset", "lookup", "region=eu,us").
+		Message("preset missing").
+		InternalMessage("sql: no rows in result set").
+		Build(time.Now().UTC())
+
+	st := ToStatus(ce, WithInternalMessage(true))
+	if st.Code() != codes.NotFound {
+		t.Errorf("want code %s but got %s", codes.NotFound, st.Code())
+	}
+	if st.Message() != "preset missing" {
+		t.Errorf("want message %q but got %q", "preset missing", st.Message())
+	}
+
+	got := FromStatus(st)
+	if got.StatusCode != ce.StatusCode ||
+		got.Status != ce.Status ||
+		got.CustomCode != ce.CustomCode ||
+		got.CorrelationID != ce.CorrelationID ||
+		got.Source != ce.Source ||
+		got.Message != ce.Message ||
+		got.InternalMessage != ce.InternalMessage ||
+		!reflect.DeepEqual(got.Tags, ce.Tags) {
+		t.Errorf("FromStatus(ToStatus()) = \n%+v\nwant \n%+v\n", got, ce)
+	}
+}
+
+func TestToStatus_InternalMessage(t *testing.T) {
+	ce := errors.NewCloudErrorBuilder().
+		StatusCode(500).
+		Message("something went wrong").
+		InternalMessage("pq: password authentication failed for user \"svc\"").
+		Build(time.Now().UTC())
+
+	t.Run("when not asked for the internal message should not be sent", func(t *testing.T) {
+		if got := FromStatus(ToStatus(ce)); got.InternalMessage != "" {
+			t.Errorf("want no internal message but got %q", got.InternalMessage)
+		}
+	})
+
+	t.Run("when asked for the internal message should be sent", func(t *testing.T) {
+		if got := FromStatus(ToStatus(ce, WithInternalMessage(true))); got.InternalMessage != ce.InternalMessage {
+			t.Errorf("want internal message %q but got %q", ce.InternalMessage, got.InternalMessage)
+		}
+	})
+}
+
+func TestToStatus_KeepsUnmappedStatusCodes(t *testing.T) {
+	ce := errors.NewCloudError(418, "short and stout")
+
+	st := ToStatus(ce)
+	if st.Code() != codes.FailedPrecondition {
+		t.Errorf("want code %s but got %s", codes.FailedPrecondition, st.Code())
+	}
+	if got := FromStatus(st); got.StatusCode != 418 || got.CustomCode != ce.CustomCode {
+		t.Errorf("want status code 418 %s but got %d %s", ce.CustomCode, got.StatusCode, got.CustomCode)
+	}
+}
+
+func TestToStatus_NonErrorStatusCodes(t *testing.T) {
+	for _, statusCode := range []int{200, 204, 302} {
+		t.Run(fmt.Sprint(statusCode), func(t *testing.T) {
+			ce := errors.NewCloudError(statusCode, "not really an error")
+
+			err := ToStatus(ce).Err()
+			if err == nil {
+				t.Fatalf("want a status error but got nil")
+			}
+			if got := status.Code(err); got != codes.Unknown {
+				t.Errorf("want code %s but got %s", codes.Unknown, got)
+			}
+		})
+	}
+}
+
+func TestToStatus_FieldErrors(t *testing.T) {
+	ce := errors.NewCloudErrorBuilder().
+		FieldError("name", "required", "is required", nil).
+		Build(time.Now().UTC())
+
+	got := FromStatus(ToStatus(ce))
+	want := errors.FieldErrors{{Field: "name", Message: "is required"}}
+	if got.StatusCode != 400 || !reflect.DeepEqual(got.FieldErrors, want) {
+		t.Errorf("want a 400 with %+v but got %d %+v", want, got.StatusCode, got.FieldErrors)
+	}
+}
+
+func TestFromStatus(t *testing.T) {
+	t.Run("when the status is OK it should return nil", func(t *testing.T) {
+		if got := FromStatus(status.New(codes.OK, "")); got != nil {
+			t.Errorf("want nil but got %v", got)
+		}
+	})
+
+	t.Run("when the status has no details it should be synthesized from the code", func(t *testing.T) {
+		got := FromStatus(status.New(codes.Unavailable, "connection reset"))
+		if got.StatusCode != 503 || got.CustomCode != "ServiceUnavailable" || got.Message != "connection reset" {
+			t.Errorf("unexpected cloud error %+v", got)
+		}
+	})
+}
+
+func TestCodeMapping(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		code       codes.Code
+	}{
+		{400, codes.InvalidArgument},
+		{401, codes.Unauthenticated},
+		{403, codes.PermissionDenied},
+		{404, codes.NotFound},
+		{409, codes.AlreadyExists},
+		{429, codes.ResourceExhausted},
+		{500, codes.Internal},
+		{501, codes.Unimplemented},
+		{503, codes.Unavailable},
+		{504, codes.DeadlineExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.statusCode), func(t *testing.T) {
+			if got := CodeFromHTTPStatus(tt.statusCode); got != tt.code {
+				t.Errorf("CodeFromHTTPStatus(%d) = %s, want %s", tt.statusCode, got, tt.code)
+			}
+			if got := HTTPStatusFromCode(tt.code); got != tt.statusCode {
+				t.Errorf("HTTPStatusFromCode(%s) = %d, want %d", tt.code, got, tt.statusCode)
+			}
+		})
+	}
+}
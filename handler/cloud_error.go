@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	errs "errors"
+	"net/http"
+	"time"
+
+	"github.com/music-tribe/errors"
+)
+
+// response converts err into the CloudError that is sent to the client,
+// passing the full error on to the metrics and reporters on the way, and
+// encodes it in the representation that best matches accept. Every handler
+// in this package goes through it so that they all render errors the same
+// way.
+func (o *options) response(ctx context.Context, err error, h http.Header, route string) (*errors.CloudError, string, []byte) {
+	ce := o.toCloudError(ctx, err, o.correlationID(h))
+	if o.hides(ce) && ce.CorrelationID == "" {
+		// the generic message is all the client gets, so it needs an ID to
+		// quote that can be found in the reports
+		ce.CorrelationID = errors.NewCorrelationID()
+	}
+	o.notify(ctx, o.reportRedactor.CloudError(ce), route)
+
+	ce = o.forClient(ctx, ce, h)
+	contentType, body := render(h.Get("Accept"), ce)
+
+	byt, encErr := json.Marshal(body)
+	if encErr != nil {
+		o.failed(ctx, ce, encErr)
+
+		ce = fallback(ce)
+		contentType, body = render(h.Get("Accept"), ce)
+		byt, _ = json.Marshal(body)
+	}
+
+	return ce, contentType, append(byt, '\n')
+}
+
+// toCloudError converts any error into a CloudError. The correlation ID falls
+// back to the one stored in ctx.
+func (o *options) toCloudError(ctx context.Context, err error, correlationID string) *errors.CloudError {
+	ce := o.cloudErrorFrom(ctx, err)
+	if correlationID == "" {
+		correlationID = errors.CorrelationIDFromContext(ctx)
+	}
+	if correlationID != "" {
+		ce.CorrelationID = correlationID
+	}
+
+	return ce
+}
+
+func (o *options) cloudErrorFrom(ctx context.Context, err error) *errors.CloudError {
+	ce := &errors.CloudError{}
+	if errs.As(err, &ce) {
+		// the error may be shared, e.g. a package-level sentinel returned by
+		// concurrent requests, so the request's fields are set on a copy
+		c := *ce
+		return &c
+	}
+
+	for _, convert := range o.converters {
+		if ce, ok := convert(ctx, err); ok {
+			return ce
+		}
+	}
+	for _, convert := range defaultConverters {
+		if ce, ok := convert(ctx, err); ok {
+			return ce
+		}
+	}
+
+	return errors.NewCloudErrorCtx(ctx, o.defaultStatus, err)
+}
+
+// GenericMessageKey is the message key of the message that replaces the
+// messages of server errors in production mode, so that it can be translated
+// with WithCatalog. Its template can refer to {correlation_id}.
+const GenericMessageKey = "errors.internal"
+
+func genericMessage(correlationID string) string {
+	if correlationID == "" {
+		return "An internal error occurred."
+	}
+
+	return "An internal error occurred. Please quote reference " + correlationID + " when reporting it."
+}
+
+// forClient returns a copy of ce to send to the client, with its message in
+// the language the request h prefers. Locations, stack traces, internal
+// messages and causes are only for developers' eyes, so they are left out
+// unless verbose, server errors are hidden in production mode, and what is
+// left is redacted. The errors of the items of a
+// CloudErrorList are treated the same way.
+func (o *options) forClient(ctx context.Context, ce *errors.CloudError, h http.Header) *errors.CloudError {
+	var langs []string
+	if o.catalog != nil {
+		langs = append(acceptLanguages(h.Get("Accept-Language")), o.languages...)
+	}
+
+	out := o.redactor.CloudError(o.strip(ce, langs))
+	if o.transform != nil {
+		return o.transform(ctx, out)
+	}
+
+	return out
+}
+
+// hides reports whether the message of ce is replaced by the generic one.
+func (o *options) hides(ce *errors.CloudError) bool {
+	return o.production && ce.StatusCode >= 500 && !ce.IsSafe()
+}
+
+func (o *options) strip(ce *errors.CloudError, langs []string) *errors.CloudError {
+	out := *ce
+	if o.hides(ce) {
+		out.Message = genericMessage(ce.CorrelationID)
+		out.MessageKey = GenericMessageKey
+		out.MessageArgs = nil
+		if ce.CorrelationID != "" {
+			out.MessageArgs = map[string]any{"correlation_id": ce.CorrelationID}
+		}
+		out.Details = nil
+		out.FieldErrors = nil
+	}
+	if o.catalog != nil {
+		out.Message = out.Localize(o.catalog, langs...)
+	}
+	if !o.verbose {
+		out.ErrorLocation = errors.ErrorLocation{}
+		out.Stack = nil
+		out.InternalMessage = ""
+		out.InternalError = nil
+	}
+	for _, field := range o.redact {
+		redactField(&out, field)
+	}
+
+	if len(ce.Items) > 0 {
+		out.Items = make([]errors.CloudErrorItem, len(ce.Items))
+		for i, item := range ce.Items {
+			out.Items[i] = errors.CloudErrorItem{Index: item.Index, Error: o.strip(item.Error, langs)}
+		}
+	}
+
+	return &out
+}
+
+func redactField(ce *errors.CloudError, field string) {
+	switch field {
+	case "message":
+		ce.Message = ""
+	case "internal_message":
+		ce.InternalMessage = ""
+	case "message_key":
+		ce.MessageKey = ""
+	case "message_args":
+		ce.MessageArgs = nil
+	case "source":
+		ce.Source = ""
+	case "timestamp":
+		ce.TimeStamp = time.Time{}
+	case "location":
+		ce.ErrorLocation = errors.ErrorLocation{}
+	case "correlation_id":
+		ce.CorrelationID = ""
+	case "user_id":
+		ce.UserID = ""
+	case "tenant_id":
+		ce.TenantID = ""
+	case "tags":
+		ce.Tags = nil
+	case "details":
+		ce.Details = nil
+	case "field_errors":
+		ce.FieldErrors = nil
+	case "items":
+		ce.Items = nil
+	case "internal_error":
+		ce.InternalError = nil
+	case "stack":
+		ce.Stack = nil
+	}
+}
+
+// fallback is the error sent when ce cannot be encoded; it holds nothing that
+// can fail to encode, so not even ce's timestamp.
+func fallback(ce *errors.CloudError) *errors.CloudError {
+	return &errors.CloudError{
+		StatusCode:    ce.StatusCode,
+		Status:        ce.Status,
+		Message:       ce.Message,
+		Source:        ce.Source,
+		TimeStamp:     time.Now().UTC(),
+		CustomCode:    ce.CustomCode,
+		CorrelationID: ce.CorrelationID,
+	}
+}
+
+func (o *options) correlationID(h http.Header) string {
+	if o.correlationHeader != "" {
+		return errors.CorrelationIDFromHeaders(h, o.correlationHeader)
+	}
+
+	return errors.CorrelationIDFromHeader(h)
+}
+
+// seedContext stores the request's correlation ID in its context, so that
+// errors built with errors.NewCloudErrorCtx further down carry it. A new ID is
+// generated if the request has none, and the ID is echoed back in the
+// response headers h.
+func (o *options) seedContext(h http.Header, r *http.Request) *http.Request {
+	id := errors.CorrelationIDFromContext(r.Context())
+	if id == "" {
+		id = o.correlationID(r.Header)
+	}
+	if id == "" {
+		id = errors.NewCorrelationID()
+	}
+
+	if o.correlationHeader != "" {
+		errors.SetCorrelationIDHeaders(h, id, o.correlationHeader)
+	} else {
+		errors.SetCorrelationIDHeader(h, id)
+	}
+
+	return r.WithContext(errors.WithCorrelationID(r.Context(), id))
+}
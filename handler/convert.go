@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	errs "errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/music-tribe/errors"
+)
+
+// Converter converts an error of a type it knows, such as a framework's error
+// type, into a CloudError. It returns false for any other error.
+type Converter func(ctx context.Context, err error) (*errors.CloudError, bool)
+
+// defaultConverters are tried after any set with WithConverter.
+var defaultConverters = []Converter{ConvertBindingError, ConvertHTTPError}
+
+// ConvertHTTPError converts an echo.HTTPError. A string or error message
+// becomes the message; any other message, such as a map from a validator,
+// becomes the details, with the status text as the message. The internal
+// error is kept as the cause, and if it is a *json.UnmarshalTypeError, as when
+// binding a body fails, the field it names becomes a field error.
+func ConvertHTTPError(ctx context.Context, err error) (*errors.CloudError, bool) {
+	he := &echo.HTTPError{}
+	if !errs.As(err, &he) {
+		return nil, false
+	}
+
+	msg, details := httpErrorMessage(he)
+	ce := fromHTTPError(ctx, he, msg, details)
+
+	ute := &json.UnmarshalTypeError{}
+	if errs.As(he.Internal, &ute) && ute.Field != "" {
+		// ute.Value describes the JSON value, e.g. "string", rather than
+		// holding it, so there is no rejected value to give
+		ce.FieldErrors = errors.FieldErrors{
+			errors.NewFieldError(ute.Field, "type", "must be of type "+ute.Type.String(), nil),
+		}
+	}
+
+	return ce, true
+}
+
+// ConvertBindingError converts an echo.BindingError, returned when binding
+// path or query parameters fails, like ConvertHTTPError but with a field error
+// for the parameter, and the details holding the name of the parameter and
+// any structured message.
+func ConvertBindingError(ctx context.Context, err error) (*errors.CloudError, bool) {
+	be := &echo.BindingError{}
+	if !errs.As(err, &be) || be.HTTPError == nil {
+		return nil, false
+	}
+
+	msg, structured := httpErrorMessage(be.HTTPError)
+	details := map[string]any{"field": be.Field}
+	if structured != nil {
+		details["message"] = structured
+	}
+
+	ce := fromHTTPError(ctx, be.HTTPError, msg, details)
+	ce.FieldErrors = errors.FieldErrors{
+		errors.NewFieldError(be.Field, "type", msg, strings.Join(be.Values, ",")),
+	}
+
+	return ce, true
+}
+
+// httpErrorMessage splits the message of he into a string message and, if it
+// is neither a string nor an error, the structured message itself.
+func httpErrorMessage(he *echo.HTTPError) (string, any) {
+	switch m := he.Message.(type) {
+	case string:
+		return m, nil
+	case error:
+		return m.Error(), nil
+	case nil:
+		return http.StatusText(he.Code), nil
+	default:
+		return http.StatusText(he.Code), m
+	}
+}
+
+func fromHTTPError(ctx context.Context, he *echo.HTTPError, msg string, details any) *errors.CloudError {
+	return errors.NewCloudErrorBuilder().
+		StatusCode(he.Code).
+		Error(he.Internal).
+		Message(msg).
+		Details(details).
+		Context(ctx).
+		Build(time.Now().UTC())
+}
+
+// WithConverter adds converters for other error types, which are tried in
+// order before the built-in ones for echo errors.
+func WithConverter(converters ...Converter) Option {
+	return func(o *options) {
+		o.converters = append(o.converters, converters...)
+	}
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"context"
+	errs "errors"
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/music-tribe/errors"
+)
+
+func TestConvertHTTPError(t *testing.T) {
+	internal := fmt.Errorf("strconv.Atoi: parsing \"abc\": invalid syntax")
+
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantMessage string
+		wantDetails any
+		wantCause   error
+	}{
+		{
+			name:        "when the message is a string",
+			err:         echo.NewHTTPError(404, "preset missing"),
+			wantStatus:  404,
+			wantMessage: "preset missing",
+		},
+		{
+			name:        "when the message is structured it should become the details",
+			err:         echo.NewHTTPError(422, map[string]any{"name": "required"}),
+			wantStatus:  422,
+			wantMessage: "Unprocessable Entity",
+			wantDetails: map[string]any{"name": "required"},
+		},
+		{
+			name:        "when the message is an error",
+			err:         echo.NewHTTPError(400, fmt.Errorf("bad json")),
+			wantStatus:  400,
+			wantMessage: "bad json",
+		},
+		{
+			name:        "when there is an internal error it should be kept as the cause",
+			err:         echo.NewHTTPError(400, "invalid id").SetInternal(internal),
+			wantStatus:  400,
+			wantMessage: "invalid id",
+			wantCause:   internal,
+		},
+		{
+			name:        "when the error is wrapped",
+			err:         fmt.Errorf("handler: %w", echo.ErrUnauthorized),
+			wantStatus:  401,
+			wantMessage: "Unauthorized",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ce, ok := ConvertHTTPError(context.Background(), tt.err)
+			if !ok {
+				t.Fatal("want the error to be converted")
+			}
+
+			if ce.StatusCode != tt.wantStatus || ce.Message != tt.wantMessage {
+				t.Errorf("want %d %q but got %d %q", tt.wantStatus, tt.wantMessage, ce.StatusCode, ce.Message)
+			}
+			if !reflect.DeepEqual(ce.Details, tt.wantDetails) {
+				t.Errorf("want details %v but got %v", tt.wantDetails, ce.Details)
+			}
+			if ce.InternalError != tt.wantCause {
+				t.Errorf("want cause %v but got %v", tt.wantCause, ce.InternalError)
+			}
+		})
+	}
+
+	t.Run("when the error is not an echo.HTTPError it should not be converted", func(t *testing.T) {
+		if _, ok := ConvertHTTPError(context.Background(), fmt.Errorf("boom")); ok {
+			t.Error("want the error not to be converted")
+		}
+	})
+}
+
+func TestConvertBindingError(t *testing.T) {
+	t.Run("when a parameter fails to bind the field should be in the details", func(t *testing.T) {
+		var id int
+		err := echo.QueryParamsBinder(echo.New().NewContext(httptest.NewRequest("GET", "/?id=abc", nil), nil)).MustInt("id", &id).BindError()
+
+		ce, ok := ConvertBindingError(context.Background(), err)
+		if !ok {
+			t.Fatalf("want %T to be converted", err)
+		}
+		if ce.StatusCode != 400 || !reflect.DeepEqual(ce.Details, map[string]any{"field": "id"}) {
+			t.Errorf("unexpected error %d %v", ce.StatusCode, ce.Details)
+		}
+		if len(ce.FieldErrors) != 1 || ce.FieldErrors[0].Field != "id" || ce.FieldErrors[0].Rule != "type" {
+			t.Errorf("want a field error for id but got %+v", ce.FieldErrors)
+		}
+		if !errs.Is(ce, &errors.CloudError{StatusCode: 400}) || ce.InternalError == nil {
+			t.Errorf("want a 400 with the parse error as its cause but got %+v", ce)
+		}
+	})
+}
+
+type quotaError struct{}
+
+func (quotaError) Error() string { return "quota exceeded" }
+
+func TestWithConverter(t *testing.T) {
+	setEnvironment(t, "production")
+
+	convert := func(ctx context.Context, err error) (*errors.CloudError, bool) {
+		if !errs.As(err, &quotaError{}) {
+			return nil, false
+		}
+		return errors.NewCloudErrorCtx(ctx, 429, err.Error()), true
+	}
+
+	t.Run("when a converter knows the error it should be used", func(t *testing.T) {
+		rec, ce := handleWithOptions(t, fmt.Errorf("upload: %w", quotaError{}), nil, WithConverter(convert))
+		if rec.Code != 429 || ce.Message != "upload: quota exceeded" {
+			t.Errorf("want a 429 but got %d %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("when a converter does not know the error the built-in conversion should be used", func(t *testing.T) {
+		rec, _ := handleWithOptions(t, echo.ErrNotFound, nil, WithConverter(convert))
+		if rec.Code != 404 {
+			t.Errorf("want a 404 but got %d", rec.Code)
+		}
+	})
+}
+
+func TestConvertHTTPError_FieldErrors(t *testing.T) {
+	t.Run("when binding the body fails on a field type it should be a field error", func(t *testing.T) {
+		e := echo.New()
+		req := httptest.NewRequest("POST", "/", strings.NewReader(`{"id":"abc"}`))
+		req.Header.Set("Content-Type", "application/json")
+		var body struct {
+			ID int `json:"id"`
+		}
+		err := e.NewContext(req, httptest.NewRecorder()).Bind(&body)
+
+		ce, ok := ConvertHTTPError(context.Background(), err)
+		if !ok {
+			t.Fatalf("want %T to be converted", err)
+		}
+		want := errors.FieldErrors{{Field: "id", Rule: "type", Message: "must be of type int"}}
+		if ce.StatusCode != 400 || !reflect.DeepEqual(ce.FieldErrors, want) {
+			t.Errorf("want %+v but got %d %+v", want, ce.StatusCode, ce.FieldErrors)
+		}
+	})
+
+	t.Run("when rejected values are included the JSON kind should not be given as one", func(t *testing.T) {
+		errors.SetRejectedValuePolicy(errors.IncludeRejectedValues)
+		t.Cleanup(func() { errors.SetRejectedValuePolicy(errors.OmitRejectedValues) })
+
+		req := httptest.NewRequest("POST", "/", strings.NewReader(`{"id":"abc"}`))
+		req.Header.Set("Content-Type", "application/json")
+		var body struct {
+			ID int `json:"id"`
+		}
+		err := echo.New().NewContext(req, httptest.NewRecorder()).Bind(&body)
+
+		ce, _ := ConvertHTTPError(context.Background(), err)
+		if len(ce.FieldErrors) != 1 || ce.FieldErrors[0].RejectedValue != nil {
+			t.Errorf("want no rejected value but got %+v", ce.FieldErrors)
+		}
+	})
+}
@@ -1,58 +1,74 @@
 package handler
 
 import (
-	errs "errors"
-	"os"
+	"net/http"
+	"reflect"
 
 	"github.com/labstack/echo/v4"
-	"github.com/music-tribe/errors"
 )
 
-const correlationIDHeader = "X-Request-ID"
+// NewCustomHTTPErrorHandler returns an echo.HTTPErrorHandler that writes
+// errors to the client as CloudErrors, or as Problem Details documents if the
+// request prefers them. Responses to HEAD requests have no body, and errors
+// returned after the response was committed are only passed to the metrics,
+// reporters and write failure hook.
+func NewCustomHTTPErrorHandler(opts ...Option) func(error, echo.Context) {
+	o := newOptions(opts)
 
-func NewCustomHTTPErrorHandler() func(error, echo.Context) {
 	return func(err error, c echo.Context) {
-		code := 500
-		msg := err.Error()
-		correlationID := c.Request().Header.Get(correlationIDHeader)
-
-		ce := &errors.CloudError{}
-		if errs.As(err, &ce) {
-			ce.CorrelationID = correlationID
-			if !isDevEnv() {
-				ce.ErrorLocation = errors.ErrorLocation{}
-			}
-			_ = c.JSON(ce.StatusCode, ce)
-			return
-		}
-
-		he := &echo.HTTPError{}
-		if errs.As(err, &he) {
-			if heMsg, ok := he.Message.(string); ok {
-				msg = heMsg
-			}
-
-			heErr := errors.NewCloudError(he.Code, msg)
-			if !isDevEnv() {
-				heErr.ErrorLocation = errors.ErrorLocation{}
-			}
+		ctx := c.Request().Context()
+		ce, contentType, body := o.response(ctx, err, c.Request().Header, echoRoute(c))
 
-			heErr.CorrelationID = correlationID
-
-			_ = c.JSON(he.Code, heErr)
+		// the handler has already started its own response, so all that can
+		// be done is to report the error
+		if c.Response().Committed {
+			o.failed(ctx, ce, ErrResponseCommitted)
 			return
 		}
 
-		outErr := errors.NewCloudError(code, msg)
-		outErr.CorrelationID = correlationID
-		if !isDevEnv() {
-			outErr.ErrorLocation = errors.ErrorLocation{}
+		c.Response().Header().Add(echo.HeaderVary, echo.HeaderAccept)
+		if o.catalog != nil {
+			c.Response().Header().Add(echo.HeaderVary, "Accept-Language")
+		}
+		if c.Request().Method == http.MethodHead {
+			c.Response().Header().Set(echo.HeaderContentType, contentType)
+			err = c.NoContent(ce.StatusCode)
+		} else {
+			err = c.Blob(ce.StatusCode, contentType, body)
+		}
+		if err != nil {
+			o.failed(ctx, ce, err)
 		}
+	}
+}
 
-		_ = c.JSON(code, outErr)
+// echoRoute returns the route template that handled the request, or "" if the
+// router matched no route. echo reports the raw request path then, which would
+// give every mistyped or scanned URL its own route.
+func echoRoute(c echo.Context) string {
+	if h := c.Handler(); h != nil {
+		fn := reflect.ValueOf(h).Pointer()
+		if fn == reflect.ValueOf(echo.NotFoundHandler).Pointer() ||
+			fn == reflect.ValueOf(echo.MethodNotAllowedHandler).Pointer() {
+			return ""
+		}
 	}
+
+	return c.Path()
 }
 
-func isDevEnv() bool {
-	return os.Getenv("ENVIRONMENT") == "dev"
+// NewEchoMiddleware returns echo middleware that stores the request's
+// correlation ID in the request context, so that errors built with
+// errors.NewCloudErrorCtx carry it before they reach the error handler. If the
+// request has no correlation ID one is generated, and the ID is echoed back in
+// the response headers. Only the correlation header option is used.
+func NewEchoMiddleware(opts ...Option) echo.MiddlewareFunc {
+	o := newOptions(opts)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.SetRequest(o.seedContext(c.Response().Header(), c.Request()))
+			return next(c)
+		}
+	}
 }
@@ -1,14 +1,19 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/labstack/echo/v4"
 	"github.com/music-tribe/errors"
+	"github.com/music-tribe/errors/report"
 )
 
 const (
@@ -25,6 +30,7 @@ func TestCustomHTTPErrorHandler(t *testing.T) {
 		args              args
 		wantStatusCode    int
 		wantErrMsg        string
+		wantInternalMsg   string
 		wantLocation      bool
 		wantCorrelationID string
 	}{
@@ -35,7 +41,8 @@ func TestCustomHTTPErrorHandler(t *testing.T) {
 				"dev",
 			},
 			wantStatusCode:    500,
-			wantErrMsg:        "this is a standard error",
+			wantErrMsg:        "Internal Server Error",
+			wantInternalMsg:   "this is a standard error",
 			wantLocation:      true,
 			wantCorrelationID: testCorrelationID,
 		},
@@ -46,7 +53,7 @@ func TestCustomHTTPErrorHandler(t *testing.T) {
 				"production",
 			},
 			wantStatusCode:    500,
-			wantErrMsg:        "this is a standard prod error",
+			wantErrMsg:        "An internal error occurred. Please quote reference " + testCorrelationID + " when reporting it.",
 			wantLocation:      false,
 			wantCorrelationID: testCorrelationID,
 		},
@@ -97,10 +104,10 @@ func TestCustomHTTPErrorHandler(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			t.Setenv("ENVIRONMENT", tt.args.env)
+			setEnvironment(t, tt.args.env)
 
 			req := httptest.NewRequest("GET", "/", nil)
-			req.Header.Set(correlationIDHeader, testCorrelationID)
+			req.Header.Set(errors.HeaderRequestID, testCorrelationID)
 			rec := httptest.NewRecorder()
 			ctx := echo.New().NewContext(req, rec)
 
@@ -124,6 +131,11 @@ func TestCustomHTTPErrorHandler(t *testing.T) {
 				return
 			}
 
+			if ce.InternalMessage != tt.wantInternalMsg {
+				t.Errorf("want internal msg to be %q but got %q\n", tt.wantInternalMsg, ce.InternalMessage)
+				return
+			}
+
 			if ce.CorrelationID != tt.wantCorrelationID {
 				t.Errorf("want correlation id to be %s but got %s\n", tt.wantCorrelationID, ce.CorrelationID)
 				return
@@ -136,3 +148,304 @@ func TestCustomHTTPErrorHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestCustomHTTPErrorHandler_Cause(t *testing.T) {
+	err := errors.NewCloudError(500, fmt.Errorf("pq: password authentication failed"))
+
+	t.Run("when not in dev the cause should not be sent", func(t *testing.T) {
+		setEnvironment(t, "production")
+		rec := httptest.NewRecorder()
+		NewCustomHTTPErrorHandler()(err, echo.New().NewContext(httptest.NewRequest("GET", "/", nil), rec))
+
+		if strings.Contains(rec.Body.String(), "internal_error") {
+			t.Errorf("want no cause but got %s", rec.Body.String())
+		}
+	})
+
+	t.Run("when in dev the cause should be sent", func(t *testing.T) {
+		setEnvironment(t, "dev")
+		rec := httptest.NewRecorder()
+		NewCustomHTTPErrorHandler()(errors.NewCloudError(500, fmt.Errorf("pq: password authentication failed")), echo.New().NewContext(httptest.NewRequest("GET", "/", nil), rec))
+
+		if !strings.Contains(rec.Body.String(), `"internal_error":{"message":"pq: password authentication failed"`) {
+			t.Errorf("want the cause but got %s", rec.Body.String())
+		}
+	})
+}
+
+func TestCustomHTTPErrorHandler_Stack(t *testing.T) {
+	tests := []struct {
+		env       string
+		wantStack bool
+	}{
+		{"dev", true},
+		{"production", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			setEnvironment(t, tt.env)
+
+			req := httptest.NewRequest("GET", "/", nil)
+			rec := httptest.NewRecorder()
+			ctx := echo.New().NewContext(req, rec)
+
+			NewCustomHTTPErrorHandler()(errors.NewCloudError(500, "boom", errors.CaptureStackOption()), ctx)
+
+			ce := new(errors.CloudError)
+			if err := json.Unmarshal(rec.Body.Bytes(), ce); err != nil {
+				t.Fatal(err)
+			}
+
+			if gotStack := len(ce.StackTrace()) > 0; gotStack != tt.wantStack {
+				t.Errorf("want stack to be %v but got %+v\n", tt.wantStack, ce.StackTrace())
+			}
+		})
+	}
+}
+
+func TestNewEchoMiddleware(t *testing.T) {
+	t.Run("when the request has a correlation ID it should be stored in the context", func(t *testing.T) {
+		var got string
+		h := NewEchoMiddleware()(func(c echo.Context) error {
+			got = errors.NewCloudErrorCtx(c.Request().Context(), 404, "missing").CorrelationID
+			return nil
+		})
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(errors.HeaderRequestID, testCorrelationID)
+		if err := h(echo.New().NewContext(req, httptest.NewRecorder())); err != nil {
+			t.Fatal(err)
+		}
+
+		if got != testCorrelationID {
+			t.Errorf("want correlation id %s but got %s", testCorrelationID, got)
+		}
+	})
+
+	t.Run("when the error handler gets an error without a correlation ID header it should use the context", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req = req.WithContext(errors.WithCorrelationID(req.Context(), testCorrelationID))
+		rec := httptest.NewRecorder()
+
+		NewCustomHTTPErrorHandler()(fmt.Errorf("boom"), echo.New().NewContext(req, rec))
+
+		ce := new(errors.CloudError)
+		if err := json.Unmarshal(rec.Body.Bytes(), ce); err != nil {
+			t.Fatal(err)
+		}
+		if ce.CorrelationID != testCorrelationID {
+			t.Errorf("want correlation id %s but got %s", testCorrelationID, ce.CorrelationID)
+		}
+	})
+}
+
+func TestNewEchoMiddleware_CorrelationID(t *testing.T) {
+	t.Run("when the request has no correlation ID the error should carry a generated one", func(t *testing.T) {
+		e := echo.New()
+		e.HTTPErrorHandler = NewCustomHTTPErrorHandler()
+		e.Use(NewEchoMiddleware())
+		e.GET("/", func(c echo.Context) error {
+			return fmt.Errorf("boom")
+		})
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+		ce := new(errors.CloudError)
+		if err := json.Unmarshal(rec.Body.Bytes(), ce); err != nil {
+			t.Fatal(err)
+		}
+		if ce.CorrelationID == "" || rec.Header().Get(errors.HeaderRequestID) != ce.CorrelationID {
+			t.Errorf("want a generated correlation id echoed back but got %q and header %q", ce.CorrelationID, rec.Header().Get(errors.HeaderRequestID))
+		}
+	})
+
+	t.Run("when a shared error is returned by concurrent requests it should be left as it is", func(t *testing.T) {
+		sentinel := errors.NewCloudError(409, "preset is locked")
+
+		e := echo.New()
+		e.HTTPErrorHandler = NewCustomHTTPErrorHandler()
+		e.Use(NewEchoMiddleware())
+		e.GET("/", func(c echo.Context) error {
+			return sentinel
+		})
+
+		var wg sync.WaitGroup
+		ids := make([]string, 8)
+		for i := range ids {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				rec := httptest.NewRecorder()
+				e.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+				ce := new(errors.CloudError)
+				_ = json.Unmarshal(rec.Body.Bytes(), ce)
+				ids[i] = ce.CorrelationID
+			}(i)
+		}
+		wg.Wait()
+
+		if sentinel.CorrelationID != "" {
+			t.Errorf("want the shared error to be left alone but its correlation id is %q", sentinel.CorrelationID)
+		}
+		for i, id := range ids {
+			if id == "" || (i > 0 && id == ids[0]) {
+				t.Errorf("want each response to carry its own correlation id but got %v", ids)
+				break
+			}
+		}
+	})
+}
+
+func TestNewCustomHTTPErrorHandler_WithMetrics(t *testing.T) {
+	t.Run("when an error is handled it should be observed with the echo route", func(t *testing.T) {
+		m := &testMetrics{}
+		e := echo.New()
+		e.HTTPErrorHandler = NewCustomHTTPErrorHandler(WithMetrics(m))
+		e.GET("/presets/:id", func(c echo.Context) error {
+			return errors.NewCloudError(404, "preset missing")
+		})
+
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/presets/1", nil))
+
+		if len(m.errors) != 1 || m.errors[0].StatusCode != 404 || m.routes[0] != "/presets/:id" {
+			t.Errorf("unexpected observations %+v %v", m.errors, m.routes)
+		}
+	})
+
+	t.Run("when no route matches it should be observed without a route", func(t *testing.T) {
+		m := &testMetrics{}
+		e := echo.New()
+		e.HTTPErrorHandler = NewCustomHTTPErrorHandler(WithMetrics(m))
+		e.GET("/presets/:id", func(c echo.Context) error { return nil })
+
+		for _, req := range []*http.Request{
+			httptest.NewRequest("GET", "/random/abc123", nil),
+			httptest.NewRequest("GET", "/random/def456", nil),
+			httptest.NewRequest("DELETE", "/presets/1", nil),
+		} {
+			e.ServeHTTP(httptest.NewRecorder(), req)
+		}
+
+		if !reflect.DeepEqual(m.routes, []string{"", "", ""}) {
+			t.Errorf("want no route for unmatched requests but got %q", m.routes)
+		}
+	})
+}
+
+func TestNewCustomHTTPErrorHandler_WithReporter(t *testing.T) {
+	setEnvironment(t, "production")
+
+	tests := []struct {
+		name         string
+		ranges       []report.StatusRange
+		err          error
+		wantReported bool
+	}{
+		{name: "when a 5xx error is handled it should be reported", err: errors.NewCloudError(500, "boom"), wantReported: true},
+		{name: "when a 4xx error is handled it should not be reported by default", err: errors.NewCloudError(404, "missing"), wantReported: false},
+		{name: "when a 4xx error is in a configured range it should be reported", ranges: []report.StatusRange{{From: 400, To: 499}}, err: errors.NewCloudError(404, "missing"), wantReported: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *errors.CloudError
+			r := report.ReporterFunc(func(_ context.Context, ce *errors.CloudError) { got = ce })
+
+			rec := httptest.NewRecorder()
+			NewCustomHTTPErrorHandler(WithReporter(r, tt.ranges...))(tt.err, echo.New().NewContext(httptest.NewRequest("GET", "/", nil), rec))
+
+			if (got != nil) != tt.wantReported {
+				t.Fatalf("want reported to be %v but got %v", tt.wantReported, got != nil)
+			}
+			if got != nil && got.ErrorLocation.Line == 0 {
+				t.Errorf("want the reported error to keep its location")
+			}
+			if strings.Contains(rec.Body.String(), `"line"`) {
+				t.Errorf("want the location to be left out of the response but got %s", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestNewCustomHTTPErrorHandler_Committed(t *testing.T) {
+	t.Run("when a streaming endpoint fails mid-response the error should be reported rather than written", func(t *testing.T) {
+		var failed error
+		reported := 0
+
+		e := echo.New()
+		e.HTTPErrorHandler = NewCustomHTTPErrorHandler(
+			WithReporter(report.ReporterFunc(func(context.Context, *errors.CloudError) { reported++ })),
+			WithWriteFailure(func(_ context.Context, _ *errors.CloudError, err error) { failed = err }),
+		)
+		e.GET("/stream", func(c echo.Context) error {
+			c.Response().Header().Set(echo.HeaderContentType, "text/event-stream")
+			c.Response().WriteHeader(200)
+			_, _ = c.Response().Write([]byte("data: 1\n\n"))
+			c.Response().Flush()
+
+			return fmt.Errorf("upstream closed")
+		})
+
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest("GET", "/stream", nil))
+
+		if rec.Code != 200 || rec.Body.String() != "data: 1\n\n" {
+			t.Errorf("want the stream to be left alone but got %d %q", rec.Code, rec.Body.String())
+		}
+		if failed != ErrResponseCommitted || reported != 1 {
+			t.Errorf("want the error reported and ErrResponseCommitted passed to the hook but got %d and %v", reported, failed)
+		}
+	})
+}
+
+func TestNewCustomHTTPErrorHandler_Head(t *testing.T) {
+	t.Run("when a HEAD request fails only the headers should be sent", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		NewCustomHTTPErrorHandler()(errors.NewCloudError(404, "missing"), echo.New().NewContext(httptest.NewRequest("HEAD", "/", nil), rec))
+
+		if rec.Code != 404 || rec.Body.Len() != 0 {
+			t.Errorf("want a 404 with no body but got %d %q", rec.Code, rec.Body.String())
+		}
+		if got := rec.Header().Get(echo.HeaderContentType); got != echo.MIMEApplicationJSONCharsetUTF8 {
+			t.Errorf("want content type %s but got %s", echo.MIMEApplicationJSONCharsetUTF8, got)
+		}
+	})
+}
+
+func TestNewCustomHTTPErrorHandler_List(t *testing.T) {
+	setEnvironment(t, "production")
+
+	list := errors.NewCloudErrorList().
+		Add(1, errors.NewCloudError(400, "name is required")).
+		Add(4, errors.NewCloudError(409, "preset is locked"))
+	list.Partial = true
+
+	t.Run("when a batch partly fails it should be a 207 with every item", func(t *testing.T) {
+		rec, ce := handleWithOptions(t, fmt.Errorf("import: %w", list), nil)
+		if rec.Code != 207 || len(ce.Items) != 2 {
+			t.Fatalf("want a 207 with 2 items but got %d %s", rec.Code, rec.Body.String())
+		}
+		if ce.Items[0].Index != 1 || ce.Items[1].Index != 4 || ce.Items[1].Error.StatusCode != 409 {
+			t.Errorf("unexpected items %+v", ce.Items)
+		}
+	})
+
+	t.Run("when not verbose the items should not have locations", func(t *testing.T) {
+		rec, _ := handleWithOptions(t, list, nil)
+		if strings.Contains(rec.Body.String(), `"line"`) {
+			t.Errorf("want no locations but got %s", rec.Body.String())
+		}
+	})
+
+	t.Run("when problem details are wanted the items should be members", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept", "application/problem+json")
+		rec := httptest.NewRecorder()
+		NewCustomHTTPErrorHandler()(list, echo.New().NewContext(req, rec))
+
+		if !strings.Contains(rec.Body.String(), `"items":[{"index":1,"error":{"status_code":400`) {
+			t.Errorf("want the items in %s", rec.Body.String())
+		}
+	})
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+)
+
+// WriteError writes err to w in the CloudError JSON format, or as a Problem
+// Details document if the request prefers one, exactly as the echo error
+// handler would. Behind NewHTTPMiddleware it uses the middleware's options,
+// and errors are not written if the response was already committed.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	optionsFrom(r.Context()).writeError(w, r, err)
+}
+
+func (o *options) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	ce, contentType, body := o.response(r.Context(), err, r.Header, o.routeOf(r))
+
+	if tw, ok := w.(interface{ isCommitted() bool }); ok && tw.isCommitted() {
+		o.failed(r.Context(), ce, ErrResponseCommitted)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Add("Vary", "Accept")
+	if o.catalog != nil {
+		w.Header().Add("Vary", "Accept-Language")
+	}
+	w.WriteHeader(ce.StatusCode)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err := w.Write(body); err != nil {
+		o.failed(r.Context(), ce, err)
+	}
+}
+
+// HandlerFunc is an http.Handler that can return an error. Any returned error
+// is written to the client with WriteError.
+type HandlerFunc func(http.ResponseWriter, *http.Request) error
+
+func (fn HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := fn(w, r); err != nil {
+		WriteError(w, r, err)
+	}
+}
+
+// NewHTTPMiddleware returns net/http middleware that stores the request's
+// correlation ID in the request context, generating one if needed and echoing
+// it back in the response headers, and recovers from panics in the wrapped
+// handler and writes the panic value to the client as a CloudError. WriteError
+// and HandlerFunc behind the middleware use its options. It can be used with
+// any router that accepts func(http.Handler) http.Handler middleware, such as
+// chi.
+func NewHTTPMiddleware(opts ...Option) func(http.Handler) http.Handler {
+	o := newOptions(opts)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w = newTrackingWriter(w)
+			r = o.seedContext(w.Header(), r)
+			r = r.WithContext(context.WithValue(r.Context(), optionsKey{}, o))
+
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				err, ok := rec.(error)
+				if !ok {
+					err = fmt.Errorf("%v", rec)
+				}
+
+				WriteError(w, r, err)
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// trackingWriter records whether the response has been committed, so that
+// WriteError does not write over a response the handler has started.
+type trackingWriter struct {
+	http.ResponseWriter
+	committed bool
+}
+
+// newTrackingWriter wraps w in a trackingWriter that still satisfies the
+// optional http.Hijacker and io.ReaderFrom interfaces when w does, so that
+// handlers behind the middleware can upgrade connections and use sendfile.
+func newTrackingWriter(w http.ResponseWriter) http.ResponseWriter {
+	tw := &trackingWriter{ResponseWriter: w}
+
+	_, hijacker := w.(http.Hijacker)
+	_, readerFrom := w.(io.ReaderFrom)
+	switch {
+	case hijacker && readerFrom:
+		return &hijackReaderFromWriter{tw}
+	case hijacker:
+		return &hijackWriter{tw}
+	case readerFrom:
+		return &readerFromWriter{tw}
+	}
+	return tw
+}
+
+func (w *trackingWriter) isCommitted() bool {
+	return w.committed
+}
+
+func (w *trackingWriter) WriteHeader(statusCode int) {
+	w.committed = true
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *trackingWriter) Write(b []byte) (int, error) {
+	w.committed = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *trackingWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.committed = true
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (w *trackingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *trackingWriter) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.committed = true
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+func (w *trackingWriter) readFrom(r io.Reader) (int64, error) {
+	w.committed = true
+	return w.ResponseWriter.(io.ReaderFrom).ReadFrom(r)
+}
+
+type hijackWriter struct{ *trackingWriter }
+
+func (w *hijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) { return w.hijack() }
+
+type readerFromWriter struct{ *trackingWriter }
+
+func (w *readerFromWriter) ReadFrom(r io.Reader) (int64, error) { return w.readFrom(r) }
+
+type hijackReaderFromWriter struct{ *trackingWriter }
+
+func (w *hijackReaderFromWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) { return w.hijack() }
+
+func (w *hijackReaderFromWriter) ReadFrom(r io.Reader) (int64, error) { return w.readFrom(r) }
@@ -0,0 +1,473 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/music-tribe/errors"
+)
+
+func TestWriteError(t *testing.T) {
+	type args struct {
+		err error
+		env string
+	}
+	tests := []struct {
+		name              string
+		args              args
+		wantStatusCode    int
+		wantErrMsg        string
+		wantInternalMsg   string
+		wantLocation      bool
+		wantCorrelationID string
+	}{
+		{
+			name: "When it's a standard golang error",
+			args: args{
+				fmt.Errorf("this is a standard error"),
+				"dev",
+			},
+			wantStatusCode:    500,
+			wantErrMsg:        "Internal Server Error",
+			wantInternalMsg:   "this is a standard error",
+			wantLocation:      true,
+			wantCorrelationID: testCorrelationID,
+		},
+		{
+			name: "When it's a standard golang error on production env",
+			args: args{
+				fmt.Errorf("this is a standard prod error"),
+				"production",
+			},
+			wantStatusCode:    500,
+			wantErrMsg:        "An internal error occurred. Please quote reference " + testCorrelationID + " when reporting it.",
+			wantLocation:      false,
+			wantCorrelationID: testCorrelationID,
+		},
+		{
+			name: "When the error is an MT cloud error",
+			args: args{
+				errors.NewCloudError(403, "do one!"),
+				"dev",
+			},
+			wantStatusCode:    403,
+			wantErrMsg:        "do one!",
+			wantLocation:      true,
+			wantCorrelationID: testCorrelationID,
+		},
+		{
+			name: "When the error is an MT cloud error on staging",
+			args: args{
+				errors.NewCloudError(403, "do one!"),
+				"staging",
+			},
+			wantStatusCode:    403,
+			wantErrMsg:        "do one!",
+			wantLocation:      false,
+			wantCorrelationID: testCorrelationID,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setEnvironment(t, tt.args.env)
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set(errors.HeaderRequestID, testCorrelationID)
+			rec := httptest.NewRecorder()
+
+			WriteError(rec, req, tt.args.err)
+
+			if rec.Code != tt.wantStatusCode {
+				t.Errorf("want response status code to be %d but got %d\n", tt.wantStatusCode, rec.Code)
+				return
+			}
+
+			ce := new(errors.CloudError)
+			if err := json.Unmarshal(rec.Body.Bytes(), ce); err != nil {
+				t.Fatal(err)
+			}
+
+			if ce.StatusCode != tt.wantStatusCode {
+				t.Errorf("want status code to be %d but got %d\n", tt.wantStatusCode, ce.StatusCode)
+				return
+			}
+
+			if ce.Message != tt.wantErrMsg {
+				t.Errorf("want errors msg to be %s but got %s\n", tt.wantErrMsg, ce.Message)
+				return
+			}
+
+			if ce.InternalMessage != tt.wantInternalMsg {
+				t.Errorf("want internal msg to be %q but got %q\n", tt.wantInternalMsg, ce.InternalMessage)
+				return
+			}
+
+			if ce.CorrelationID != tt.wantCorrelationID {
+				t.Errorf("want correlation id to be %s but got %s\n", tt.wantCorrelationID, ce.CorrelationID)
+				return
+			}
+
+			if !reflect.DeepEqual(ce.ErrorLocation, errors.ErrorLocation{}) != tt.wantLocation {
+				t.Errorf("want location to be %v but got %+v\n", tt.wantLocation, ce.ErrorLocation)
+				return
+			}
+		})
+	}
+}
+
+func TestWriteError_MatchesEchoHandler(t *testing.T) {
+	setEnvironment(t, "production")
+
+	ce := errors.NewCloudError(404, "preset missing", errors.SetCorrelationIDOption("ignored"))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(errors.HeaderRequestID, testCorrelationID)
+
+	echoRec := httptest.NewRecorder()
+	NewCustomHTTPErrorHandler()(ce, echo.New().NewContext(req, echoRec))
+
+	httpRec := httptest.NewRecorder()
+	WriteError(httpRec, req, ce)
+
+	if echoRec.Code != httpRec.Code {
+		t.Errorf("want status code %d but got %d", echoRec.Code, httpRec.Code)
+	}
+	if echoRec.Header().Get("Content-Type") != httpRec.Header().Get("Content-Type") {
+		t.Errorf("want content type %s but got %s", echoRec.Header().Get("Content-Type"), httpRec.Header().Get("Content-Type"))
+	}
+	if echoRec.Body.String() != httpRec.Body.String() {
+		t.Errorf("want body \n%s\nbut got \n%s\n", echoRec.Body.String(), httpRec.Body.String())
+	}
+}
+
+func TestHandlerFunc(t *testing.T) {
+	setEnvironment(t, "production")
+
+	t.Run("when the handler returns an error it should be written as a cloud error", func(t *testing.T) {
+		h := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			return errors.NewCloudError(409, "already exists")
+		})
+
+		req := httptest.NewRequest("POST", "/", nil)
+		req.Header.Set(errors.HeaderRequestID, testCorrelationID)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		ce := new(errors.CloudError)
+		if err := json.Unmarshal(rec.Body.Bytes(), ce); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Code != 409 || ce.StatusCode != 409 || ce.CorrelationID != testCorrelationID {
+			t.Errorf("unexpected response %d %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("when the handler returns nil the response should be left alone", func(t *testing.T) {
+		h := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			w.WriteHeader(204)
+			return nil
+		})
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+		if rec.Code != 204 || rec.Body.Len() != 0 {
+			t.Errorf("unexpected response %d %s", rec.Code, rec.Body.String())
+		}
+	})
+}
+
+func TestNewHTTPMiddleware(t *testing.T) {
+	setEnvironment(t, "production")
+
+	tests := []struct {
+		name           string
+		panicWith      any
+		wantStatusCode int
+		wantErrMsg     string
+	}{
+		{
+			name:           "when the handler panics with a cloud error",
+			panicWith:      errors.NewCloudError(403, "do one!"),
+			wantStatusCode: 403,
+			wantErrMsg:     "do one!",
+		},
+		{
+			name:           "when the handler panics with a standard error",
+			panicWith:      fmt.Errorf("nil map"),
+			wantStatusCode: 500,
+			wantErrMsg:     "An internal error occurred. Please quote reference " + testCorrelationID + " when reporting it.",
+		},
+		{
+			name:           "when the handler panics with a string",
+			panicWith:      "something broke",
+			wantStatusCode: 500,
+			wantErrMsg:     "An internal error occurred. Please quote reference " + testCorrelationID + " when reporting it.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHTTPMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic(tt.panicWith)
+			}))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set(errors.HeaderRequestID, testCorrelationID)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			ce := new(errors.CloudError)
+			if err := json.Unmarshal(rec.Body.Bytes(), ce); err != nil {
+				t.Fatal(err)
+			}
+
+			if rec.Code != tt.wantStatusCode || ce.StatusCode != tt.wantStatusCode {
+				t.Errorf("want status code to be %d but got %d\n", tt.wantStatusCode, ce.StatusCode)
+			}
+			if ce.Message != tt.wantErrMsg {
+				t.Errorf("want errors msg to be %s but got %s\n", tt.wantErrMsg, ce.Message)
+			}
+			if ce.CorrelationID != testCorrelationID {
+				t.Errorf("want correlation id to be %s but got %s\n", testCorrelationID, ce.CorrelationID)
+			}
+		})
+	}
+
+	t.Run("when the handler aborts the panic should be propagated", func(t *testing.T) {
+		h := NewHTTPMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}))
+
+		defer func() {
+			if rec := recover(); rec != http.ErrAbortHandler {
+				t.Errorf("expected http.ErrAbortHandler to be re-panicked but got %v", rec)
+			}
+		}()
+
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	})
+}
+
+func TestNewHTTPMiddleware_SeedsContext(t *testing.T) {
+	t.Run("when the request has a correlation ID it should be stored in the context", func(t *testing.T) {
+		var got string
+		h := NewHTTPMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = errors.NewCloudErrorCtx(r.Context(), 404, "missing").CorrelationID
+		}))
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(errors.HeaderRequestID, testCorrelationID)
+		h.ServeHTTP(httptest.NewRecorder(), req)
+
+		if got != testCorrelationID {
+			t.Errorf("want correlation id %s but got %s", testCorrelationID, got)
+		}
+	})
+}
+
+func TestNewHTTPMiddleware_CorrelationID(t *testing.T) {
+	t.Run("when the request has no correlation ID one should be generated and echoed back", func(t *testing.T) {
+		var got string
+		h := NewHTTPMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = errors.CorrelationIDFromContext(r.Context())
+		}))
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+		if got == "" || rec.Header().Get(errors.HeaderRequestID) != got {
+			t.Errorf("want a generated correlation id echoed back but got %q and header %q", got, rec.Header().Get(errors.HeaderRequestID))
+		}
+	})
+
+	t.Run("when the request has a correlation ID it should be echoed back", func(t *testing.T) {
+		h := NewHTTPMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(errors.HeaderRequestID, testCorrelationID)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get(errors.HeaderRequestID); got != testCorrelationID {
+			t.Errorf("want correlation id %s but got %s", testCorrelationID, got)
+		}
+	})
+
+	t.Run("when a different header is configured it should be used", func(t *testing.T) {
+		errors.SetCorrelationHeaders(errors.HeaderCorrelationID)
+		defer errors.SetCorrelationHeaders(errors.HeaderRequestID)
+
+		h := NewHTTPMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		}))
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(errors.HeaderCorrelationID, testCorrelationID)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		ce := new(errors.CloudError)
+		if err := json.Unmarshal(rec.Body.Bytes(), ce); err != nil {
+			t.Fatal(err)
+		}
+		if ce.CorrelationID != testCorrelationID || rec.Header().Get(errors.HeaderCorrelationID) != testCorrelationID {
+			t.Errorf("want correlation id %s but got %s", testCorrelationID, ce.CorrelationID)
+		}
+	})
+}
+
+type testMetrics struct {
+	errors []*errors.CloudError
+	routes []string
+}
+
+func (m *testMetrics) ObserveError(_ context.Context, ce *errors.CloudError, route string) {
+	m.errors = append(m.errors, ce)
+	m.routes = append(m.routes, route)
+}
+
+func TestNewHTTPMiddleware_WithMetrics(t *testing.T) {
+	t.Run("when a handler behind the middleware returns an error it should be observed", func(t *testing.T) {
+		m := &testMetrics{}
+		h := NewHTTPMiddleware(WithMetrics(m), WithRoute(func(r *http.Request) string { return "/presets/{id}" }))(
+			HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+				return errors.NewCloudError(404, "preset missing")
+			}),
+		)
+
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/presets/1", nil))
+
+		if len(m.errors) != 1 || m.errors[0].StatusCode != 404 || m.routes[0] != "/presets/{id}" {
+			t.Errorf("unexpected observations %+v %v", m.errors, m.routes)
+		}
+	})
+}
+
+func TestNewHTTPMiddleware_Committed(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.Handler
+	}{
+		{
+			name: "when a streaming handler returns an error mid-response",
+			handler: HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+				_, _ = w.Write([]byte("chunk 1\n"))
+				http.NewResponseController(w).Flush()
+				return fmt.Errorf("upstream closed")
+			}),
+		},
+		{
+			name: "when a streaming handler panics mid-response",
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("chunk 1\n"))
+				panic("upstream closed")
+			}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name+" the error should not be written", func(t *testing.T) {
+			var failed error
+			h := NewHTTPMiddleware(WithWriteFailure(func(_ context.Context, _ *errors.CloudError, err error) { failed = err }))(tt.handler)
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+			if rec.Code != 200 || rec.Body.String() != "chunk 1\n" {
+				t.Errorf("want the stream to be left alone but got %d %q", rec.Code, rec.Body.String())
+			}
+			if failed != ErrResponseCommitted {
+				t.Errorf("want ErrResponseCommitted but got %v", failed)
+			}
+		})
+	}
+
+	t.Run("when a HEAD request fails only the headers should be sent", func(t *testing.T) {
+		h := NewHTTPMiddleware()(HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			return errors.NewCloudError(404, "missing")
+		}))
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("HEAD", "/", nil))
+
+		if rec.Code != 404 || rec.Body.Len() != 0 || rec.Header().Get("Content-Type") == "" {
+			t.Errorf("want a 404 with headers and no body but got %d %q", rec.Code, rec.Body.String())
+		}
+	})
+}
+
+func TestNewHTTPMiddleware_OptionalInterfaces(t *testing.T) {
+	t.Run("when a handler hijacks the connection it should get it and the error should not be written", func(t *testing.T) {
+		failed := make(chan error, 1)
+		h := NewHTTPMiddleware(WithWriteFailure(func(_ context.Context, _ *errors.CloudError, err error) { failed <- err }))(
+			HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+				hj, ok := w.(http.Hijacker)
+				if !ok {
+					return fmt.Errorf("not a hijacker")
+				}
+				conn, rw, err := hj.Hijack()
+				if err != nil {
+					return err
+				}
+				defer conn.Close()
+
+				_, _ = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n")
+				_ = rw.Flush()
+				return fmt.Errorf("peer went away")
+			}),
+		)
+		srv := httptest.NewServer(h)
+		defer srv.Close()
+
+		conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		_, _ = conn.Write([]byte("GET / HTTP/1.1\r\nHost: test\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n\r\n"))
+
+		resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusSwitchingProtocols {
+			t.Errorf("want the connection to be upgraded but got %d", resp.StatusCode)
+		}
+		if got := <-failed; got != ErrResponseCommitted {
+			t.Errorf("want ErrResponseCommitted but got %v", got)
+		}
+	})
+
+	t.Run("when the response writer is a reader from it should stay one", func(t *testing.T) {
+		h := NewHTTPMiddleware()(HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			rf, ok := w.(io.ReaderFrom)
+			if !ok {
+				return fmt.Errorf("not a reader from")
+			}
+			_, err := rf.ReadFrom(strings.NewReader("file contents"))
+			return err
+		}))
+		srv := httptest.NewServer(h)
+		defer srv.Close()
+
+		resp, err := http.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != 200 || string(body) != "file contents" {
+			t.Errorf("want the contents to be sent but got %d %q", resp.StatusCode, body)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/music-tribe/errors"
+)
+
+// render picks the representation of ce that best matches the request's
+// Accept header, returning its content type and the value to encode. Clients
+// that explicitly prefer application/problem+json get a Problem Details
+// document, everyone else gets the legacy CloudError shape.
+func render(accept string, ce *errors.CloudError) (string, any) {
+	if wantsProblemJSON(accept) {
+		return errors.MIMEApplicationProblemJSON, ce.Problem()
+	}
+	return echo.MIMEApplicationJSONCharsetUTF8, ce
+}
+
+func wantsProblemJSON(accept string) bool {
+	problemQ, jsonQ := -1.0, -1.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+
+		switch mediaType {
+		case errors.MIMEApplicationProblemJSON:
+			if q > problemQ {
+				problemQ = q
+			}
+		case echo.MIMEApplicationJSON:
+			if q > jsonQ {
+				jsonQ = q
+			}
+		}
+	}
+
+	return problemQ > 0 && problemQ >= jsonQ
+}
+
+// acceptLanguages returns the languages of an Accept-Language header, most
+// preferred first. The wildcard and languages with a q of 0 are left out.
+func acceptLanguages(header string) []string {
+	type weighted struct {
+		lang string
+		q    float64
+	}
+
+	var langs []weighted
+	for _, part := range strings.Split(header, ",") {
+		lang, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		lang = strings.TrimSpace(lang)
+		if lang == "" || lang == "*" {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q > 0 {
+			langs = append(langs, weighted{lang, q})
+		}
+	}
+
+	sort.SliceStable(langs, func(i, j int) bool { return langs[i].q > langs[j].q })
+
+	out := make([]string, len(langs))
+	for i, l := range langs {
+		out[i] = l.lang
+	}
+
+	return out
+}
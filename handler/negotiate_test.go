@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/music-tribe/errors"
+)
+
+func TestWantsProblemJSON(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"", false},
+		{"*/*", false},
+		{"application/json", false},
+		{"application/problem+json", true},
+		{"application/json, application/problem+json", true},
+		{"application/problem+json;q=0.5, application/json", false},
+		{"application/problem+json, application/json;q=0.9", true},
+		{"application/problem+json;q=0", false},
+		{"text/html, application/problem+json;q=0.8, */*;q=0.1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			if got := wantsProblemJSON(tt.accept); got != tt.want {
+				t.Errorf("wantsProblemJSON(%q) = %v, want %v", tt.accept, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomHTTPErrorHandler_ProblemJSON(t *testing.T) {
+	setEnvironment(t, "production")
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(errors.HeaderRequestID, testCorrelationID)
+	req.Header.Set(echo.HeaderAccept, errors.MIMEApplicationProblemJSON)
+	rec := httptest.NewRecorder()
+
+	NewCustomHTTPErrorHandler()(errors.NewCloudError(404, "preset missing"), echo.New().NewContext(req, rec))
+
+	if got := rec.Header().Get(echo.HeaderContentType); got != errors.MIMEApplicationProblemJSON {
+		t.Errorf("want content type %s but got %s", errors.MIMEApplicationProblemJSON, got)
+	}
+	if got := rec.Header().Get(echo.HeaderVary); got != echo.HeaderAccept {
+		t.Errorf("want vary header %s but got %s", echo.HeaderAccept, got)
+	}
+	if rec.Code != 404 {
+		t.Errorf("want status code 404 but got %d", rec.Code)
+	}
+
+	pd := new(errors.ProblemDetails)
+	if err := json.Unmarshal(rec.Body.Bytes(), pd); err != nil {
+		t.Fatal(err)
+	}
+	if pd.Status != 404 || pd.Title != "Not Found" || pd.Detail != "preset missing" ||
+		pd.Instance != testCorrelationID || pd.Code != errors.NotFound {
+		t.Errorf("unexpected problem details %+v", pd)
+	}
+	if pd.Location != nil {
+		t.Errorf("want location to be stripped outside dev but got %+v", pd.Location)
+	}
+}
+
+func TestWriteError_ProblemJSON(t *testing.T) {
+	setEnvironment(t, "dev")
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "application/problem+json, application/json;q=0.9")
+	rec := httptest.NewRecorder()
+
+	WriteError(rec, req, errors.NewCloudError(400, "bad preset"))
+
+	if got := rec.Header().Get("Content-Type"); got != errors.MIMEApplicationProblemJSON {
+		t.Errorf("want content type %s but got %s", errors.MIMEApplicationProblemJSON, got)
+	}
+
+	pd := new(errors.ProblemDetails)
+	if err := json.Unmarshal(rec.Body.Bytes(), pd); err != nil {
+		t.Fatal(err)
+	}
+	if pd.Status != 400 || pd.Detail != "bad preset" {
+		t.Errorf("unexpected problem details %+v", pd)
+	}
+	if pd.Location == nil {
+		t.Errorf("want location to be included in dev")
+	}
+}
+
+func TestAcceptLanguages(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{"", []string{}},
+		{"de", []string{"de"}},
+		{"fr;q=0.5, de-AT, en;q=0.8", []string{"de-AT", "en", "fr"}},
+		{"*, es;q=0, it;q=0.1", []string{"it"}},
+	}
+	for _, tt := range tests {
+		t.Run("when the header is "+tt.header, func(t *testing.T) {
+			if got := acceptLanguages(tt.header); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("want %v but got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"context"
+	errs "errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/music-tribe/errors"
+	"github.com/music-tribe/errors/report"
+)
+
+// ErrResponseCommitted is passed to the write failure hook when an error is
+// returned after the response has been committed, so it cannot be written.
+var ErrResponseCommitted = errs.New("handler: response already committed")
+
+// Metrics is notified of every CloudError the handlers write. route is the
+// route template that handled the request, e.g. "/presets/:id", if known.
+type Metrics interface {
+	ObserveError(ctx context.Context, ce *errors.CloudError, route string)
+}
+
+// Option configures the error handlers.
+type Option func(*options)
+
+type options struct {
+	verbose           bool
+	production        bool
+	correlationHeader string
+	defaultStatus     int
+	transform         func(context.Context, *errors.CloudError) *errors.CloudError
+	redact            []string
+	converters        []Converter
+	metrics           Metrics
+	reporters         []report.Reporter
+	route             func(*http.Request) string
+	writeFailed       func(context.Context, *errors.CloudError, error)
+	catalog           errors.Catalog
+	languages         []string
+	redactor          *errors.Redactor
+	reportRedactor    *errors.Redactor
+}
+
+func newOptions(opts []Option) *options {
+	dev := os.Getenv("ENVIRONMENT") == "dev"
+	o := &options{
+		verbose:       dev,
+		production:    !dev,
+		defaultStatus: http.StatusInternalServerError,
+		redactor:      &errors.Redactor{Rules: []errors.RedactionRule{errors.SecretRule}},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	// the details of hidden server errors must end up somewhere
+	if o.production && len(o.reporters) == 0 {
+		WithLogger(slog.Default())(o)
+	}
+
+	return o
+}
+
+// WithVerbose sets whether the location, stack trace, internal message and
+// cause of errors are sent to clients. It defaults to whether the ENVIRONMENT
+// env var is "dev" when the handler is created.
+func WithVerbose(verbose bool) Option {
+	return func(o *options) {
+		o.verbose = verbose
+	}
+}
+
+// WithProductionMode sets whether server errors are hidden from clients: the
+// message of a 5xx error is replaced by a generic one quoting its correlation
+// ID, which is generated if it has none, and its details are left out, unless
+// it was marked safe with the builder's Safe method or errors.SafeOption. The
+// full error is still passed to the reporters, and if there are none it is
+// logged with slog.Default. It defaults to whether the ENVIRONMENT env var is
+// not "dev" when the handler is created.
+func WithProductionMode(production bool) Option {
+	return func(o *options) {
+		o.production = production
+	}
+}
+
+// WithCorrelationHeader sets the header the correlation ID is read from and
+// echoed back in, instead of the headers set with
+// errors.SetCorrelationHeaders. As there, the trace ID is the correlation ID
+// of the traceparent header.
+func WithCorrelationHeader(name string) Option {
+	return func(o *options) {
+		o.correlationHeader = name
+	}
+}
+
+// WithDefaultStatus sets the status code of errors that are not CloudErrors
+// or echo.HTTPErrors. It defaults to 500.
+func WithDefaultStatus(statusCode int) Option {
+	return func(o *options) {
+		o.defaultStatus = statusCode
+	}
+}
+
+// WithTransform sets a hook that is given the error that is about to be sent
+// to the client, after the location has been stripped and fields redacted,
+// and returns the error to send instead. The error it is given is a copy, so
+// it may be modified.
+func WithTransform(fn func(context.Context, *errors.CloudError) *errors.CloudError) Option {
+	return func(o *options) {
+		o.transform = fn
+	}
+}
+
+// WithRedactedFields leaves the named fields out of the errors sent to
+// clients. Fields are named as in the CloudError JSON, e.g. "internal_error"
+// or "tags".
+func WithRedactedFields(fields ...string) Option {
+	return func(o *options) {
+		o.redact = append(o.redact, fields...)
+	}
+}
+
+// WithCatalog translates the messages of errors with a MessageKey into the
+// language the request prefers in its Accept-Language header, falling back to
+// the fallback languages in order. Errors without a translation keep their
+// message.
+func WithCatalog(cat errors.Catalog, fallback ...string) Option {
+	return func(o *options) {
+		o.catalog = cat
+		o.languages = fallback
+	}
+}
+
+// WithRedactor sets the redactor applied to the errors sent to clients. It
+// defaults to one that removes secrets with errors.SecretRule; nil turns
+// redaction off.
+func WithRedactor(r *errors.Redactor) Option {
+	return func(o *options) {
+		o.redactor = r
+	}
+}
+
+// WithReportRedactor sets the redactor applied to the errors passed to the
+// metrics, reporters and loggers, which may be more lenient than the one for
+// clients. They are not redacted by default.
+func WithReportRedactor(r *errors.Redactor) Option {
+	return func(o *options) {
+		o.reportRedactor = r
+	}
+}
+
+// WithMetrics reports every CloudError written to m.
+func WithMetrics(m Metrics) Option {
+	return func(o *options) {
+		o.metrics = m
+	}
+}
+
+// WithReporter reports the errors written with a status code in one of
+// ranges to r, by default only 5xx errors. r is given the full error,
+// including its location and stack trace. It can be used more than once.
+func WithReporter(r report.Reporter, ranges ...report.StatusRange) Option {
+	if len(ranges) == 0 {
+		ranges = []report.StatusRange{report.ServerErrors}
+	}
+
+	return func(o *options) {
+		o.reporters = append(o.reporters, report.Filter(r, ranges...))
+	}
+}
+
+// WithLogger logs the errors written with a status code in one of ranges, by
+// default only 5xx errors, to logger. It is WithReporter with report.NewSlog.
+func WithLogger(logger *slog.Logger, ranges ...report.StatusRange) Option {
+	return WithReporter(report.NewSlog(logger), ranges...)
+}
+
+// WithRoute sets how the net/http handlers find the route template of a
+// request for metrics. The echo handler always uses the echo route.
+func WithRoute(fn func(*http.Request) string) Option {
+	return func(o *options) {
+		o.route = fn
+	}
+}
+
+// WithWriteFailure sets a hook that is called when an error cannot be
+// written to the client: because it could not be encoded, in which case a
+// response with just its status, code, message and correlation ID is sent
+// instead; because writing failed; or because the response was already
+// committed, in which case err is ErrResponseCommitted.
+func WithWriteFailure(fn func(ctx context.Context, ce *errors.CloudError, err error)) Option {
+	return func(o *options) {
+		o.writeFailed = fn
+	}
+}
+
+// notify passes an error that is about to be written on to the metrics and
+// reporters.
+func (o *options) notify(ctx context.Context, ce *errors.CloudError, route string) {
+	if o.metrics != nil {
+		o.metrics.ObserveError(ctx, ce, route)
+	}
+	for _, r := range o.reporters {
+		r.Report(ctx, ce)
+	}
+}
+
+func (o *options) failed(ctx context.Context, ce *errors.CloudError, err error) {
+	if o.writeFailed != nil {
+		o.writeFailed(ctx, ce, err)
+	}
+}
+
+func (o *options) routeOf(r *http.Request) string {
+	if o.route == nil {
+		return ""
+	}
+
+	return o.route(r)
+}
+
+type optionsKey struct{}
+
+// defaultOptions are used by WriteError and HandlerFunc when they are not
+// behind the middleware. Like the options of a handler, they are built once.
+var defaultOptions = sync.OnceValue(func() *options {
+	return newOptions(nil)
+})
+
+// optionsFrom returns the options stored in ctx by the net/http middleware, so
+// that WriteError and HandlerFunc behind it are configured the same way.
+func optionsFrom(ctx context.Context) *options {
+	if o, ok := ctx.Value(optionsKey{}).(*options); ok {
+		return o
+	}
+
+	return defaultOptions()
+}
@@ -0,0 +1,137 @@
+package errors
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// CloudErrorItem is the error of one item of a batch request, with the index
+// of the item in the request.
+type CloudErrorItem struct {
+	Index int         `json:"index"`
+	Error *CloudError `json:"error"`
+}
+
+// CloudErrorList holds the errors of a batch request that processes many
+// items and fails some of them.
+//
+// It unwraps to its items' errors, so errors.Is reports whether any item
+// matches, and errors.As finds the single aggregate CloudError built by
+// CloudError, which is what the handlers send.
+type CloudErrorList struct {
+	Items []CloudErrorItem `json:"items"`
+	// Partial is set when some items of the batch succeeded, which makes the
+	// aggregate status 207 Multi-Status.
+	Partial bool `json:"partial,omitempty"`
+}
+
+// NewCloudErrorList returns an empty list.
+func NewCloudErrorList() *CloudErrorList {
+	return &CloudErrorList{}
+}
+
+// Add adds the error of the item at index. Nil errors are ignored, so the
+// result of processing every item can be added.
+func (l *CloudErrorList) Add(index int, ce *CloudError) *CloudErrorList {
+	if ce != nil {
+		l.Items = append(l.Items, CloudErrorItem{Index: index, Error: ce})
+	}
+	return l
+}
+
+// Len returns the number of errors in the list.
+func (l *CloudErrorList) Len() int {
+	return len(l.Items)
+}
+
+// ErrorOrNil returns the list, or nil if it is empty, so that it can be
+// returned as an error without ending up as a non-nil error holding nil.
+func (l *CloudErrorList) ErrorOrNil() error {
+	if l == nil || len(l.Items) == 0 {
+		return nil
+	}
+	return l
+}
+
+// Error lists the errors of the items, one per line like errors.Join.
+func (l *CloudErrorList) Error() string {
+	lines := make([]string, len(l.Items))
+	for i, item := range l.Items {
+		lines[i] = fmt.Sprintf("item %d: %s", item.Index, item.Error.Error())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Unwrap returns the errors of the items.
+func (l *CloudErrorList) Unwrap() []error {
+	errList := make([]error, len(l.Items))
+	for i, item := range l.Items {
+		errList[i] = item.Error
+	}
+
+	return errList
+}
+
+// As sets a *CloudError target to the aggregate error, see CloudError.
+func (l *CloudErrorList) As(target any) bool {
+	ce, ok := target.(**CloudError)
+	if !ok || len(l.Items) == 0 {
+		return false
+	}
+
+	*ce = l.CloudError()
+	return true
+}
+
+// StatusCode returns the aggregate status of the list: 207 Multi-Status if it
+// is Partial, otherwise the highest status code of its items.
+func (l *CloudErrorList) StatusCode() int {
+	if l.Partial {
+		return http.StatusMultiStatus
+	}
+
+	statusCode := 0
+	for _, item := range l.Items {
+		if item.Error.StatusCode > statusCode {
+			statusCode = item.Error.StatusCode
+		}
+	}
+	if statusCode == 0 {
+		return http.StatusInternalServerError
+	}
+
+	return statusCode
+}
+
+// CloudError returns a single error standing for the whole list, with the
+// aggregate status code and the errors of the items in Items. Its location,
+// source and correlation ID are those of the first item, and its timestamp
+// that of the latest.
+func (l *CloudErrorList) CloudError() *CloudError {
+	statusCode := l.StatusCode()
+	ce := &CloudError{
+		StatusCode: statusCode,
+		Status:     http.StatusText(statusCode),
+		Message:    fmt.Sprintf("%d items failed", len(l.Items)),
+		CustomCode: CustomCode(strings.ReplaceAll(http.StatusText(statusCode), " ", "")),
+		Items:      l.Items,
+	}
+	if len(l.Items) == 1 {
+		ce.Message = "1 item failed"
+	}
+
+	for i, item := range l.Items {
+		if i == 0 {
+			ce.Source = item.Error.Source
+			ce.ErrorLocation = item.Error.ErrorLocation
+			ce.CorrelationID = item.Error.CorrelationID
+		}
+		if item.Error.TimeStamp.After(ce.TimeStamp) {
+			ce.TimeStamp = item.Error.TimeStamp
+		}
+	}
+
+	return ce
+}
@@ -0,0 +1,112 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	errs "errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testList() *CloudErrorList {
+	return NewCloudErrorList().
+		Add(0, NewCloudError(400, "name is required", SetCorrelationIDOption("corr-1"))).
+		Add(1, nil).
+		Add(2, NewCloudError(409, "preset is locked"))
+}
+
+func TestCloudErrorList(t *testing.T) {
+	When("items failed", t,
+		Then("the aggregate status should be the highest", func(t *testing.T) {
+			if got := testList().StatusCode(); got != 409 {
+				t.Errorf("expected 409 but got %d", got)
+			}
+		}),
+		And("it should be 207 when other items succeeded", func(t *testing.T) {
+			l := testList()
+			l.Partial = true
+			if got := l.CloudError(); got.StatusCode != 207 || got.Status != "Multi-Status" || got.CustomCode != "Multi-Status" {
+				t.Errorf("expected a 207 but got %d %s %s", got.StatusCode, got.Status, got.CustomCode)
+			}
+		}),
+		And("nil errors should not be added", func(t *testing.T) {
+			if got := testList().Len(); got != 2 {
+				t.Errorf("expected 2 errors but got %d", got)
+			}
+		}),
+		And("errors.Is should match any item", func(t *testing.T) {
+			if !errs.Is(testList(), &CloudError{StatusCode: 400}) || errs.Is(testList(), &CloudError{StatusCode: 404}) {
+				t.Error("expected errors.Is to match the items")
+			}
+		}),
+		And("errors.As should find the aggregate", func(t *testing.T) {
+			ce := &CloudError{}
+			if !errs.As(testList(), &ce) || ce.StatusCode != 409 || len(ce.Items) != 2 || ce.CorrelationID != "corr-1" {
+				t.Errorf("expected the aggregate error but got %+v", ce)
+			}
+			if ce.Message != "2 items failed" {
+				t.Errorf("unexpected message %q", ce.Message)
+			}
+		}),
+		And("the items should be serialised with their index", func(t *testing.T) {
+			byt, _ := json.Marshal(testList().CloudError())
+			if !strings.Contains(string(byt), `"items":[{"index":0,"error":{"status_code":400`) ||
+				!strings.Contains(string(byt), `{"index":2,"error":{"status_code":409`) {
+				t.Errorf("expected indexed items in %s", byt)
+			}
+
+			got := &CloudError{}
+			if err := json.Unmarshal(byt, got); err != nil || len(got.Items) != 2 || got.Items[1].Error.Message != "preset is locked" {
+				t.Errorf("expected the items to survive a round trip but got %+v (%v)", got.Items, err)
+			}
+		}),
+		And("the list itself should be serialised in snake case", func(t *testing.T) {
+			l := testList()
+			l.Partial = true
+
+			byt, _ := json.Marshal(l)
+			if !strings.HasPrefix(string(byt), `{"items":[{"index":0,`) || !strings.HasSuffix(string(byt), `"partial":true}`) {
+				t.Errorf("expected snake case members in %s", byt)
+			}
+		}),
+		And("the message should list every item", func(t *testing.T) {
+			want := "item 0: 400 BadRequest: name is required [corr=corr-1]\nitem 2: 409 Conflict: preset is locked"
+			if got := testList().Error(); got != want {
+				t.Errorf("expected %q but got %q", want, got)
+			}
+		}),
+		And("the items should be logged by index", func(t *testing.T) {
+			var buf bytes.Buffer
+			slog.New(slog.NewJSONHandler(&buf, nil)).Error("batch failed", slog.Any("error", testList().CloudError()))
+			if !strings.Contains(buf.String(), `"items":{"0":{"status_code":400`) {
+				t.Errorf("expected the items to be logged in %s", buf.String())
+			}
+		}),
+	)
+
+	When("no items failed", t,
+		Then("ErrorOrNil should return nil", func(t *testing.T) {
+			if err := NewCloudErrorList().Add(0, nil).ErrorOrNil(); err != nil {
+				t.Errorf("expected nil but got %v", err)
+			}
+		}),
+		And("errors.As should not find a CloudError", func(t *testing.T) {
+			ce := &CloudError{}
+			if errs.As(NewCloudErrorList(), &ce) {
+				t.Error("expected no CloudError")
+			}
+		}),
+	)
+
+	When("the latest item is later than the first", t,
+		Then("the aggregate timestamp should be the latest", func(t *testing.T) {
+			later := time.Now().UTC().Add(time.Hour)
+			l := testList().Add(3, NewCloudErrorBuilder().StatusCode(400).Build(later))
+			if got := l.CloudError().TimeStamp; !got.Equal(later) {
+				t.Errorf("expected %s but got %s", later, got)
+			}
+		}),
+	)
+}
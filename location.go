@@ -0,0 +1,110 @@
+package errors
+
+import (
+	"os"
+	"path"
+	"regexp"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+const serviceNameEnv = "SERVICE_NAME"
+
+var (
+	serviceMu   sync.RWMutex
+	serviceOnce sync.Once
+	serviceName string
+)
+
+// SetServiceName sets the service name recorded in the ErrorLocation of every
+// error. It is meant to be called once, at startup. If it is never called the
+// name is taken from the SERVICE_NAME env var or, failing that, from the main
+// module path in the binary's build info.
+func SetServiceName(name string) {
+	serviceOnce.Do(func() {})
+
+	serviceMu.Lock()
+	defer serviceMu.Unlock()
+	serviceName = name
+}
+
+// ServiceName returns the service name recorded in the ErrorLocation of every
+// error.
+func ServiceName() string {
+	serviceOnce.Do(func() {
+		serviceMu.Lock()
+		defer serviceMu.Unlock()
+		serviceName = defaultServiceName()
+	})
+
+	serviceMu.RLock()
+	defer serviceMu.RUnlock()
+	return serviceName
+}
+
+var majorVersionSuffix = regexp.MustCompile(`/v[0-9]+$`)
+
+func defaultServiceName() string {
+	if name := os.Getenv(serviceNameEnv); name != "" {
+		return name
+	}
+
+	bi, ok := debug.ReadBuildInfo()
+	if !ok || bi.Main.Path == "" {
+		return ""
+	}
+
+	return path.Base(majorVersionSuffix.ReplaceAllString(bi.Main.Path, ""))
+}
+
+// callerLocation returns the location of the function skip frames above the
+// caller of callerLocation, following the same convention as runtime.Caller.
+func callerLocation(skip int) ErrorLocation {
+	pc, page, line, _ := runtime.Caller(skip + 1)
+	name := runtime.FuncForPC(pc).Name()
+	pkg, fnc := splitFuncName(name)
+
+	return ErrorLocation{
+		Package:  pkg,
+		Function: fnc,
+		Method:   name,
+		Page:     page,
+		Line:     line,
+		skip:     skip,
+	}
+}
+
+// splitFuncName splits a fully qualified runtime function name such as
+// "github.com/music-tribe/errors.(*cloudErrorBuilder).Build" into its package
+// path and function name. The runtime escapes dots in the last element of the
+// package path as %2e, so the first dot after the last slash is the split.
+func splitFuncName(name string) (string, string) {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return "", name
+	}
+	dot += slash + 1
+
+	return strings.ReplaceAll(name[:dot], "%2e", "."), name[dot+1:]
+}
+
+// withGiven overlays the fields that were set explicitly on given.
+func (l ErrorLocation) withGiven(given ErrorLocation) ErrorLocation {
+	if given.Service != "" {
+		l.Service = given.Service
+	}
+	if given.Package != "" {
+		l.Package = given.Package
+	}
+	if given.Function != "" {
+		l.Function = given.Function
+	}
+	if given.Method != "" {
+		l.Method = given.Method
+	}
+
+	return l
+}
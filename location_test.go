@@ -0,0 +1,57 @@
+package errors
+
+import (
+	"testing"
+)
+
+func Test_splitFuncName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantPkg string
+		wantFnc string
+	}{
+		{"github.com/music-tribe/errors.(*cloudErrorBuilder).Build", "github.com/music-tribe/errors", "(*cloudErrorBuilder).Build"},
+		{"github.com/music-tribe/errors.TestNewCloudError.func1", "github.com/music-tribe/errors", "TestNewCloudError.func1"},
+		{"gopkg.in/check%2ev1.Suite", "gopkg.in/check.v1", "Suite"},
+		{"testing.tRunner", "testing", "tRunner"},
+		{"main.main", "main", "main"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg, fnc := splitFuncName(tt.name)
+			if pkg != tt.wantPkg || fnc != tt.wantFnc {
+				t.Errorf("splitFuncName(%q) = %q, %q want %q, %q", tt.name, pkg, fnc, tt.wantPkg, tt.wantFnc)
+			}
+		})
+	}
+}
+
+func TestServiceName(t *testing.T) {
+	When("no service name has been set", t,
+		Then("the SERVICE_NAME env var should be used", func(t *testing.T) {
+			t.Setenv(serviceNameEnv, "svc-presets")
+			if got := defaultServiceName(); got != "svc-presets" {
+				t.Errorf("expected service name to be svc-presets but got %s", got)
+			}
+		}),
+		And("the main module path should be used without the env var", func(t *testing.T) {
+			t.Setenv(serviceNameEnv, "")
+			if got := defaultServiceName(); got != "errors" {
+				t.Errorf("expected service name to be errors but got %s", got)
+			}
+		}),
+	)
+
+	When("the service name is set", t,
+		Then("it should be recorded in every error location", func(t *testing.T) {
+			prev := ServiceName()
+			SetServiceName("svc-users")
+			defer SetServiceName(prev)
+
+			if got := NewCloudError(404, "user missing"); got.ErrorLocation.Service != "svc-users" {
+				t.Errorf("expected service to be svc-users but got %s", got.ErrorLocation.Service)
+			}
+		}),
+	)
+}
@@ -0,0 +1,139 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Catalog holds the translations of message keys. Templates refer to the
+// MessageArgs of an error by name in braces, e.g. "Preset {id} not found".
+type Catalog interface {
+	// Message returns the template of key in lang, a language tag such as
+	// "de" or "en-GB".
+	Message(lang, key string) (string, bool)
+}
+
+// Messages is a Catalog held in memory, keyed by language and then by message
+// key.
+type Messages map[string]map[string]string
+
+// Message looks lang up ignoring case.
+func (m Messages) Message(lang, key string) (string, bool) {
+	msgs, ok := m[lang]
+	if !ok {
+		for l, ms := range m {
+			if strings.EqualFold(l, lang) {
+				msgs, ok = ms, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return "", false
+	}
+
+	tmpl, ok := msgs[key]
+	return tmpl, ok
+}
+
+// ReadJSON adds the messages of a JSON object of languages to message keys to
+// templates, e.g. {"de": {"preset.not_found": "Preset {id} nicht gefunden"}}.
+func (m Messages) ReadJSON(r io.Reader) error {
+	var msgs map[string]map[string]string
+	if err := json.NewDecoder(r).Decode(&msgs); err != nil {
+		return fmt.Errorf("read messages: %w", err)
+	}
+
+	for lang, ms := range msgs {
+		for key, tmpl := range ms {
+			m.add(lang, key, tmpl)
+		}
+	}
+
+	return nil
+}
+
+// ReadGotext adds the translations of a gotext messages file, such as
+// locales/de/messages.gotext.json, under its language. Messages are keyed by
+// their key, or by their id if they have none; translations that are not
+// plain strings, such as plural selections, are skipped.
+func (m Messages) ReadGotext(r io.Reader) error {
+	var file struct {
+		Language string `json:"language"`
+		Messages []struct {
+			ID          string          `json:"id"`
+			Key         string          `json:"key"`
+			Translation json.RawMessage `json:"translation"`
+		} `json:"messages"`
+	}
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return fmt.Errorf("read gotext messages: %w", err)
+	}
+	if file.Language == "" {
+		return fmt.Errorf("read gotext messages: no language")
+	}
+
+	for _, msg := range file.Messages {
+		var tmpl string
+		if err := json.Unmarshal(msg.Translation, &tmpl); err != nil || tmpl == "" {
+			continue
+		}
+
+		key := msg.Key
+		if key == "" {
+			key = msg.ID
+		}
+		m.add(file.Language, key, tmpl)
+	}
+
+	return nil
+}
+
+func (m Messages) add(lang, key, tmpl string) {
+	if m[lang] == nil {
+		m[lang] = map[string]string{}
+	}
+	m[lang][key] = tmpl
+}
+
+// Localize returns the message of the error in the first of langs that cat
+// has a translation in, with its MessageArgs filled in. A regional language
+// such as "en-GB" falls back to its base language "en" before the next is
+// tried. It returns the Message if the error has no MessageKey or there is no
+// translation.
+func (se *CloudError) Localize(cat Catalog, langs ...string) string {
+	if se.MessageKey == "" || cat == nil {
+		return se.Message
+	}
+
+	for _, lang := range langs {
+		tmpl, ok := cat.Message(lang, se.MessageKey)
+		if !ok {
+			if base, _, found := strings.Cut(lang, "-"); found {
+				tmpl, ok = cat.Message(base, se.MessageKey)
+			}
+		}
+		if ok {
+			return fillTemplate(tmpl, se.MessageArgs)
+		}
+	}
+
+	return se.Message
+}
+
+// fillTemplate replaces the names of args in braces in tmpl with their
+// values. Unknown names are left as they are.
+func fillTemplate(tmpl string, args map[string]any) string {
+	if len(args) == 0 {
+		return tmpl
+	}
+
+	oldnew := make([]string, 0, len(args)*2)
+	for name, value := range args {
+		oldnew = append(oldnew, "{"+name+"}", fmt.Sprint(value))
+	}
+
+	return strings.NewReplacer(oldnew...).Replace(tmpl)
+}
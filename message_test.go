@@ -0,0 +1,96 @@
+package errors
+
+import (
+	"strings"
+	"testing"
+)
+
+var testMessages = Messages{
+	"en": {"preset.not_found": "Preset {id} was not found"},
+	"de": {"preset.not_found": "Preset {id} wurde nicht gefunden"},
+}
+
+func TestCloudError_Localize(t *testing.T) {
+	ce := NewCloudError(404, "preset 42 not found", MessageKeyOption("preset.not_found", map[string]any{"id": 42}))
+
+	When("the catalog has the preferred language", t,
+		Then("the message should be translated with its args", func(t *testing.T) {
+			if got := ce.Localize(testMessages, "de", "en"); got != "Preset 42 wurde nicht gefunden" {
+				t.Errorf("unexpected message %q", got)
+			}
+		}),
+	)
+
+	When("the catalog only has the base language", t,
+		Then("the base language should be used", func(t *testing.T) {
+			if got := ce.Localize(testMessages, "de-AT"); got != "Preset 42 wurde nicht gefunden" {
+				t.Errorf("unexpected message %q", got)
+			}
+		}),
+	)
+
+	When("the catalog does not have the language", t,
+		Then("the next language should be used", func(t *testing.T) {
+			if got := ce.Localize(testMessages, "fr", "EN"); got != "Preset 42 was not found" {
+				t.Errorf("unexpected message %q", got)
+			}
+		}),
+		And("the message should be kept if there is no other", func(t *testing.T) {
+			if got := ce.Localize(testMessages, "fr"); got != ce.Message {
+				t.Errorf("expected the message but got %q", got)
+			}
+		}),
+	)
+
+	When("the error has no message key", t,
+		Then("the message should be kept", func(t *testing.T) {
+			if got := NewCloudError(404, "gone").Localize(testMessages, "de"); got != "gone" {
+				t.Errorf("expected the message but got %q", got)
+			}
+		}),
+	)
+}
+
+func TestMessages_Read(t *testing.T) {
+	When("messages are read from JSON", t,
+		Then("they should be added by language", func(t *testing.T) {
+			m := Messages{}
+			err := m.ReadJSON(strings.NewReader(`{"fr": {"preset.not_found": "Preset {id} introuvable"}}`))
+			if tmpl, ok := m.Message("fr", "preset.not_found"); err != nil || !ok || tmpl != "Preset {id} introuvable" {
+				t.Errorf("unexpected template %q (%v)", tmpl, err)
+			}
+		}),
+	)
+
+	When("messages are read from a gotext file", t,
+		Then("the translations should be added under its language", func(t *testing.T) {
+			m := Messages{}
+			err := m.ReadGotext(strings.NewReader(`{
+				"language": "es",
+				"messages": [
+					{"id": "Preset {ID} was not found", "key": "preset.not_found", "translation": "No se encontró el preset {ID}"},
+					{"id": "Bad request", "translation": "Solicitud incorrecta"},
+					{"id": "{N} presets", "translation": {"select": {"feature": "plural"}}}
+				]
+			}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tmpl, _ := m.Message("es", "preset.not_found"); tmpl != "No se encontró el preset {ID}" {
+				t.Errorf("expected the translation under its key but got %q", tmpl)
+			}
+			if tmpl, _ := m.Message("es", "Bad request"); tmpl != "Solicitud incorrecta" {
+				t.Errorf("expected the translation under its id but got %q", tmpl)
+			}
+			if _, ok := m.Message("es", "{N} presets"); ok {
+				t.Error("expected plural translations to be skipped")
+			}
+		}),
+		And("a file without a language should fail", func(t *testing.T) {
+			if err := (Messages{}).ReadGotext(strings.NewReader(`{"messages": []}`)); err == nil {
+				t.Error("expected an error")
+			}
+		}),
+	)
+}
@@ -0,0 +1,115 @@
+// Package metrics counts the CloudErrors written by the handler package, by
+// status code, custom code, source and route, with Prometheus or
+// OpenTelemetry. Both implement handler.Metrics:
+//
+//	m := metrics.NewPrometheus(metrics.Config{})
+//	prometheus.MustRegister(m)
+//	e.HTTPErrorHandler = handler.NewCustomHTTPErrorHandler(handler.WithMetrics(m))
+package metrics
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/music-tribe/errors"
+)
+
+// Label names of the metrics.
+const (
+	LabelStatusCode = "status_code"
+	LabelCustomCode = "custom_code"
+	LabelSource     = "source"
+	LabelRoute      = "route"
+	LabelTag        = "tag"
+)
+
+const (
+	defaultMaxValues = 100
+	// OverflowValue replaces label values once a label has MaxValues distinct
+	// values.
+	OverflowValue = "other"
+)
+
+// Config configures the metrics.
+type Config struct {
+	// MaxValues is the maximum number of distinct values recorded for each of
+	// the custom code, source and route labels. Further values are recorded
+	// as OverflowValue. It defaults to 100.
+	MaxValues int
+	// Tags are the error tags counted by the tag metric. Tags that are not
+	// listed are not counted, so that tags holding IDs and the like cannot
+	// blow up its cardinality.
+	Tags []string
+}
+
+type labels struct {
+	statusCode string
+	customCode string
+	source     string
+	route      string
+	tags       []string
+}
+
+// recorder turns CloudErrors into bounded label values.
+type recorder struct {
+	max  int
+	tags map[string]bool
+
+	mu   sync.Mutex
+	seen map[string]map[string]bool
+}
+
+func newRecorder(cfg Config) *recorder {
+	r := &recorder{
+		max:  cfg.MaxValues,
+		tags: make(map[string]bool, len(cfg.Tags)),
+		seen: map[string]map[string]bool{},
+	}
+	if r.max <= 0 {
+		r.max = defaultMaxValues
+	}
+	for _, tag := range cfg.Tags {
+		r.tags[tag] = true
+	}
+
+	return r
+}
+
+func (r *recorder) labels(ce *errors.CloudError, route string) labels {
+	l := labels{
+		statusCode: strconv.Itoa(ce.StatusCode),
+		customCode: r.bound(LabelCustomCode, string(ce.CustomCode)),
+		source:     r.bound(LabelSource, ce.Source),
+		route:      r.bound(LabelRoute, route),
+	}
+	for _, tag := range ce.Tags {
+		if r.tags[tag] {
+			l.tags = append(l.tags, tag)
+		}
+	}
+
+	return l
+}
+
+// bound returns value, or OverflowValue if label already has the maximum
+// number of distinct values and value is not one of them.
+func (r *recorder) bound(label, value string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	seen, ok := r.seen[label]
+	if !ok {
+		seen = map[string]bool{}
+		r.seen[label] = seen
+	}
+
+	if seen[value] {
+		return value
+	}
+	if len(seen) >= r.max {
+		return OverflowValue
+	}
+
+	seen[value] = true
+	return value
+}
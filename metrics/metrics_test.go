@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/music-tribe/errors"
+	"github.com/music-tribe/errors/handler"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+var (
+	_ handler.Metrics = (*Prometheus)(nil)
+	_ handler.Metrics = (*OTel)(nil)
+)
+
+func testError(code errors.CustomCode, tags ...string) *errors.CloudError {
+	return errors.NewCloudErrorBuilder().StatusCode(404).CustomCode(code).Tags(tags...).Build(time.Now().UTC())
+}
+
+func Test_recorder_labels(t *testing.T) {
+	t.Run("when a label has reached its maximum number of values new values should overflow", func(t *testing.T) {
+		rec := newRecorder(Config{MaxValues: 2})
+
+		var got []string
+		for _, code := range []errors.CustomCode{"A", "B", "C", "A"} {
+			got = append(got, rec.labels(testError(code), "/").customCode)
+		}
+
+		if want := []string{"A", "B", OverflowValue, "A"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("want custom codes %v but got %v", want, got)
+		}
+	})
+
+	t.Run("when the error has tags only the configured ones should be counted", func(t *testing.T) {
+		rec := newRecorder(Config{Tags: []string{"upload"}})
+
+		got := rec.labels(testError("A", "upload", "user-1234"), "/").tags
+		if want := []string{"upload"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("want tags %v but got %v", want, got)
+		}
+	})
+}
+
+func TestPrometheus(t *testing.T) {
+	t.Run("when errors are observed they should be counted by label", func(t *testing.T) {
+		p := NewPrometheus(Config{Tags: []string{"upload"}})
+		p.ObserveError(context.Background(), testError("PresetMissing", "upload"), "/presets/:id")
+		p.ObserveError(context.Background(), testError("PresetMissing"), "/presets/:id")
+
+		want := `
+# HELP cloud_errors_total Number of CloudErrors written to clients.
+# TYPE cloud_errors_total counter
+cloud_errors_total{custom_code="PresetMissing",route="/presets/:id",source="music-tribe",status_code="404"} 2
+# HELP cloud_error_tags_total Number of CloudErrors written to clients by tag.
+# TYPE cloud_error_tags_total counter
+cloud_error_tags_total{status_code="404",tag="upload"} 1
+`
+		if err := testutil.CollectAndCompare(p, strings.NewReader(want)); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestOTel(t *testing.T) {
+	t.Run("when errors are observed they should be counted by attribute", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		meter := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)).Meter("test")
+
+		o, err := NewOTel(meter, Config{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		o.ObserveError(context.Background(), testError("PresetMissing"), "/presets/:id")
+		o.ObserveError(context.Background(), testError("PresetMissing"), "/presets/:id")
+
+		var rm metricdata.ResourceMetrics
+		if err := reader.Collect(context.Background(), &rm); err != nil {
+			t.Fatal(err)
+		}
+
+		sum, ok := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Sum[int64])
+		if !ok || len(sum.DataPoints) != 1 {
+			t.Fatalf("want a single data point but got %+v", rm.ScopeMetrics[0].Metrics[0].Data)
+		}
+
+		dp := sum.DataPoints[0]
+		if code, _ := dp.Attributes.Value(LabelCustomCode); dp.Value != 2 || code.AsString() != "PresetMissing" {
+			t.Errorf("want 2 PresetMissing errors but got %d %v", dp.Value, dp.Attributes)
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/music-tribe/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// OTel counts CloudErrors with the cloud_errors and cloud_error_tags
+// OpenTelemetry counters.
+type OTel struct {
+	rec    *recorder
+	errors metric.Int64Counter
+	tags   metric.Int64Counter
+}
+
+// NewOTel creates the counters with meter.
+func NewOTel(meter metric.Meter, cfg Config) (*OTel, error) {
+	errs, err := meter.Int64Counter("cloud_errors",
+		metric.WithDescription("Number of CloudErrors written to clients."),
+		metric.WithUnit("{error}"))
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := meter.Int64Counter("cloud_error_tags",
+		metric.WithDescription("Number of CloudErrors written to clients by tag."),
+		metric.WithUnit("{error}"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &OTel{rec: newRecorder(cfg), errors: errs, tags: tags}, nil
+}
+
+// ObserveError implements handler.Metrics.
+func (o *OTel) ObserveError(ctx context.Context, ce *errors.CloudError, route string) {
+	l := o.rec.labels(ce, route)
+
+	o.errors.Add(ctx, 1, metric.WithAttributes(
+		attribute.String(LabelStatusCode, l.statusCode),
+		attribute.String(LabelCustomCode, l.customCode),
+		attribute.String(LabelSource, l.source),
+		attribute.String(LabelRoute, l.route),
+	))
+	for _, tag := range l.tags {
+		o.tags.Add(ctx, 1, metric.WithAttributes(
+			attribute.String(LabelTag, tag),
+			attribute.String(LabelStatusCode, l.statusCode),
+		))
+	}
+}
@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/music-tribe/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Prometheus is a prometheus.Collector counting CloudErrors in the
+// cloud_errors_total and cloud_error_tags_total metrics.
+type Prometheus struct {
+	rec    *recorder
+	errors *prometheus.CounterVec
+	tags   *prometheus.CounterVec
+}
+
+// NewPrometheus returns a Prometheus collector. It still has to be registered.
+func NewPrometheus(cfg Config) *Prometheus {
+	return &Prometheus{
+		rec: newRecorder(cfg),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cloud_errors_total",
+			Help: "Number of CloudErrors written to clients.",
+		}, []string{LabelStatusCode, LabelCustomCode, LabelSource, LabelRoute}),
+		tags: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cloud_error_tags_total",
+			Help: "Number of CloudErrors written to clients by tag.",
+		}, []string{LabelTag, LabelStatusCode}),
+	}
+}
+
+func (p *Prometheus) Describe(ch chan<- *prometheus.Desc) {
+	p.errors.Describe(ch)
+	p.tags.Describe(ch)
+}
+
+func (p *Prometheus) Collect(ch chan<- prometheus.Metric) {
+	p.errors.Collect(ch)
+	p.tags.Collect(ch)
+}
+
+// ObserveError implements handler.Metrics.
+func (p *Prometheus) ObserveError(_ context.Context, ce *errors.CloudError, route string) {
+	l := p.rec.labels(ce, route)
+
+	p.errors.WithLabelValues(l.statusCode, l.customCode, l.source, l.route).Inc()
+	for _, tag := range l.tags {
+		p.tags.WithLabelValues(tag, l.statusCode).Inc()
+	}
+}
@@ -5,3 +5,20 @@ func SetCorrelationIDOption(correlationID string) CloudErrorOption {
 		se.CorrelationID = correlationID
 	}
 }
+
+// MessageKeyOption sets the key of the message in a Catalog and the args its
+// template is filled in with.
+func MessageKeyOption(key string, args map[string]any) CloudErrorOption {
+	return func(se *CloudError) {
+		se.MessageKey = key
+		se.MessageArgs = args
+	}
+}
+
+// SafeOption marks the message of the error as safe to send to clients, so
+// that the handlers send it even for a server error in production mode.
+func SafeOption() CloudErrorOption {
+	return func(se *CloudError) {
+		se.safe = true
+	}
+}
@@ -0,0 +1,107 @@
+// Package otel links CloudErrors to OpenTelemetry traces by recording them on
+// the active span, and can use the trace ID as the correlation ID.
+package otel
+
+import (
+	"context"
+	errs "errors"
+	"fmt"
+	"strings"
+
+	"github.com/music-tribe/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attribute keys added to the exception event of a recorded CloudError.
+const (
+	AttrCustomCode    = attribute.Key("cloud_error.custom_code")
+	AttrStatusCode    = attribute.Key("cloud_error.status_code")
+	AttrSource        = attribute.Key("cloud_error.source")
+	AttrCorrelationID = attribute.Key("cloud_error.correlation_id")
+	AttrTags          = attribute.Key("cloud_error.tags")
+	// AttrStackTrace is the semantic convention key for an exception's stack.
+	AttrStackTrace = attribute.Key("exception.stacktrace")
+)
+
+// RecordError records err as an exception event on the span in ctx. If err is
+// or wraps a CloudError, the event carries its custom code, status code,
+// source, correlation ID, tags and stack trace. The span status is only set to
+// Error for 5xx status codes, since 4xx errors are the client's fault rather
+// than a failure of the span; errors that are not CloudErrors count as 500.
+func RecordError(ctx context.Context, err error) {
+	span := trace.SpanFromContext(ctx)
+	if err == nil || !span.IsRecording() {
+		return
+	}
+
+	ce := &errors.CloudError{}
+	if !errs.As(err, &ce) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+
+	span.RecordError(err, trace.WithAttributes(attributes(ce)...))
+	if ce.StatusCode >= 500 {
+		span.SetStatus(codes.Error, ce.Message)
+	}
+}
+
+func attributes(ce *errors.CloudError) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		AttrCustomCode.String(string(ce.CustomCode)),
+		AttrStatusCode.Int(ce.StatusCode),
+		AttrSource.String(ce.Source),
+	}
+	if ce.CorrelationID != "" {
+		attrs = append(attrs, AttrCorrelationID.String(ce.CorrelationID))
+	}
+	if len(ce.Tags) > 0 {
+		attrs = append(attrs, AttrTags.StringSlice(ce.Tags))
+	}
+	if frames := ce.StackTrace(); len(frames) > 0 {
+		var b strings.Builder
+		for _, frame := range frames {
+			fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		}
+		attrs = append(attrs, AttrStackTrace.String(b.String()))
+	}
+
+	return attrs
+}
+
+// TraceID returns the ID of the trace of the span in ctx, or an empty string
+// if there is none.
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+
+	return sc.TraceID().String()
+}
+
+// WithTraceCorrelationID returns a copy of ctx with the trace ID as its
+// correlation ID, see errors.WithCorrelationID, so that errors built with
+// errors.NewCloudErrorCtx and the error handlers use it. ctx is returned as it
+// is if it has no trace.
+func WithTraceCorrelationID(ctx context.Context) context.Context {
+	id := TraceID(ctx)
+	if id == "" {
+		return ctx
+	}
+
+	return errors.WithCorrelationID(ctx, id)
+}
+
+// CorrelationIDOption sets the correlation ID of an error to the trace ID of
+// the span in ctx, if it has one.
+func CorrelationIDOption(ctx context.Context) errors.CloudErrorOption {
+	return func(se *errors.CloudError) {
+		if id := TraceID(ctx); id != "" {
+			se.CorrelationID = id
+		}
+	}
+}
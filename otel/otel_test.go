@@ -0,0 +1,148 @@
+package otel
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/music-tribe/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTestSpan(t *testing.T) (context.Context, func() tracetest.SpanStub) {
+	t.Helper()
+
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "op")
+
+	return ctx, func() tracetest.SpanStub {
+		span.End()
+		spans := exporter.GetSpans()
+		if len(spans) != 1 {
+			t.Fatalf("expected 1 span but got %d", len(spans))
+		}
+		return spans[0]
+	}
+}
+
+func eventAttr(span tracetest.SpanStub, key attribute.Key) (attribute.Value, bool) {
+	for _, ev := range span.Events {
+		for _, kv := range ev.Attributes {
+			if kv.Key == key {
+				return kv.Value, true
+			}
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestRecordError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus codes.Code
+		wantCode   string
+	}{
+		{
+			name:       "when a 5xx cloud error is recorded the span status should be error",
+			err:        errors.NewCloudError(503, "database unavailable"),
+			wantStatus: codes.Error,
+			wantCode:   "ServiceUnavailable",
+		},
+		{
+			name:       "when a 4xx cloud error is recorded the span status should be left unset",
+			err:        errors.NewCloudError(404, "preset missing"),
+			wantStatus: codes.Unset,
+			wantCode:   "NotFound",
+		},
+		{
+			name:       "when a wrapped cloud error is recorded it should be found",
+			err:        fmt.Errorf("loading: %w", errors.NewCloudError(409, "conflict")),
+			wantStatus: codes.Unset,
+			wantCode:   "Conflict",
+		},
+		{
+			name:       "when a standard error is recorded it should count as a 5xx",
+			err:        fmt.Errorf("boom"),
+			wantStatus: codes.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, end := newTestSpan(t)
+			RecordError(ctx, tt.err)
+			span := end()
+
+			if span.Status.Code != tt.wantStatus {
+				t.Errorf("want span status %v but got %v", tt.wantStatus, span.Status.Code)
+			}
+			if len(span.Events) != 1 || span.Events[0].Name != "exception" {
+				t.Fatalf("want an exception event but got %+v", span.Events)
+			}
+
+			got, ok := eventAttr(span, AttrCustomCode)
+			if tt.wantCode == "" && ok {
+				t.Errorf("want no custom code but got %s", got.AsString())
+			}
+			if tt.wantCode != "" && got.AsString() != tt.wantCode {
+				t.Errorf("want custom code %s but got %s", tt.wantCode, got.AsString())
+			}
+		})
+	}
+
+	t.Run("when the cloud error has tags and a stack they should be recorded", func(t *testing.T) {
+		ctx, end := newTestSpan(t)
+		ce := errors.NewCloudError(500, "boom", errors.CaptureStackOption(), func(se *errors.CloudError) {
+			se.Tags = []string{"presets", "upload"}
+		})
+		RecordError(ctx, ce)
+		span := end()
+
+		if tags, _ := eventAttr(span, AttrTags); len(tags.AsStringSlice()) != 2 {
+			t.Errorf("want 2 tags but got %v", tags.AsStringSlice())
+		}
+		if stack, _ := eventAttr(span, AttrStackTrace); stack.AsString() == "" {
+			t.Errorf("want a stack trace to be recorded")
+		}
+	})
+
+	t.Run("when there is no span nothing should happen", func(t *testing.T) {
+		RecordError(context.Background(), errors.NewCloudError(500, "boom"))
+	})
+}
+
+func TestWithTraceCorrelationID(t *testing.T) {
+	t.Run("when the context has a span the trace ID should be the correlation ID", func(t *testing.T) {
+		ctx, end := newTestSpan(t)
+		ctx = WithTraceCorrelationID(ctx)
+		span := end()
+
+		want := span.SpanContext.TraceID().String()
+		if got := errors.NewCloudErrorCtx(ctx, 500, "boom").CorrelationID; got != want {
+			t.Errorf("want correlation id %s but got %s", want, got)
+		}
+	})
+
+	t.Run("when the context has no span it should be left alone", func(t *testing.T) {
+		ctx := errors.WithCorrelationID(context.Background(), "corr-1")
+		if got := errors.CorrelationIDFromContext(WithTraceCorrelationID(ctx)); got != "corr-1" {
+			t.Errorf("want correlation id corr-1 but got %s", got)
+		}
+	})
+}
+
+func TestCorrelationIDOption(t *testing.T) {
+	t.Run("when the context has a span the error should carry the trace ID", func(t *testing.T) {
+		ctx, end := newTestSpan(t)
+		ce := errors.NewCloudError(404, "missing", CorrelationIDOption(ctx))
+		span := end()
+
+		if want := span.SpanContext.TraceID().String(); ce.CorrelationID != want {
+			t.Errorf("want correlation id %s but got %s", want, ce.CorrelationID)
+		}
+	})
+}
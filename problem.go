@@ -0,0 +1,116 @@
+package errors
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// MIMEApplicationProblemJSON is the media type of RFC 9457 Problem Details
+// documents.
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+const defaultProblemTypeBaseURI = "urn:music-tribe:error:"
+
+var problemTypeBaseURI atomic.Pointer[string]
+
+// SetProblemTypeBaseURI changes the URI that CustomCodes are appended to when
+// building a Problem Details type. It defaults to "urn:music-tribe:error:".
+func SetProblemTypeBaseURI(uri string) {
+	problemTypeBaseURI.Store(&uri)
+}
+
+func problemTypeBase() string {
+	if uri := problemTypeBaseURI.Load(); uri != nil {
+		return *uri
+	}
+	return defaultProblemTypeBaseURI
+}
+
+// ProblemDetails is the RFC 9457 (previously RFC 7807) representation of a
+// CloudError. The members that have no standard equivalent are added as
+// extension members.
+type ProblemDetails struct {
+	Type            string           `json:"type"`
+	Title           string           `json:"title"`
+	Status          int              `json:"status"`
+	Detail          string           `json:"detail,omitempty"`
+	Instance        string           `json:"instance,omitempty"`
+	InternalMessage string           `json:"internal_message,omitempty"`
+	MessageKey      string           `json:"message_key,omitempty"`
+	MessageArgs     map[string]any   `json:"message_args,omitempty"`
+	Code            CustomCode       `json:"code,omitempty"`
+	Source          string           `json:"source,omitempty"`
+	UserID          string           `json:"user_id,omitempty"`
+	TenantID        string           `json:"tenant_id,omitempty"`
+	TimeStamp       time.Time        `json:"timestamp"`
+	Tags            []string         `json:"tags,omitempty"`
+	Details         any              `json:"details,omitempty"`
+	Errors          FieldErrors      `json:"errors,omitempty"`
+	Items           []CloudErrorItem `json:"items,omitempty"`
+	Location        *ErrorLocation   `json:"location,omitempty"`
+	Stack           *Stack           `json:"stack,omitempty"`
+}
+
+// Problem renders the error as a Problem Details document. The type is built
+// from the CustomCode, the title from the Status, the detail from the Message
+// and the instance from the CorrelationID.
+func (se *CloudError) Problem() *ProblemDetails {
+	pd := &ProblemDetails{
+		Type:            problemTypeBase() + string(se.CustomCode),
+		Title:           se.Status,
+		Status:          se.StatusCode,
+		Detail:          se.Message,
+		Instance:        se.CorrelationID,
+		InternalMessage: se.InternalMessage,
+		MessageKey:      se.MessageKey,
+		MessageArgs:     se.MessageArgs,
+		Code:            se.CustomCode,
+		Source:          se.Source,
+		UserID:          se.UserID,
+		TenantID:        se.TenantID,
+		TimeStamp:       se.TimeStamp,
+		Tags:            se.Tags,
+		Details:         se.Details,
+		Errors:          se.FieldErrors,
+		Items:           se.Items,
+		Stack:           se.Stack,
+	}
+	if se.ErrorLocation != (ErrorLocation{}) {
+		loc := se.ErrorLocation
+		pd.Location = &loc
+	}
+
+	return pd
+}
+
+// CloudError converts a Problem Details document back into a CloudError.
+func (pd *ProblemDetails) CloudError() *CloudError {
+	ce := &CloudError{
+		StatusCode:      pd.Status,
+		Status:          pd.Title,
+		Message:         pd.Detail,
+		InternalMessage: pd.InternalMessage,
+		MessageKey:      pd.MessageKey,
+		MessageArgs:     pd.MessageArgs,
+		Source:          pd.Source,
+		TimeStamp:       pd.TimeStamp,
+		CustomCode:      pd.Code,
+		CorrelationID:   pd.Instance,
+		UserID:          pd.UserID,
+		TenantID:        pd.TenantID,
+		Tags:            pd.Tags,
+		Details:         pd.Details,
+		FieldErrors:     pd.Errors,
+		Items:           pd.Items,
+		Stack:           pd.Stack,
+	}
+	if base := problemTypeBase(); ce.CustomCode == "" && strings.HasPrefix(pd.Type, base) {
+		ce.CustomCode = CustomCode(strings.TrimPrefix(pd.Type, base))
+	}
+	if pd.Location != nil {
+		ce.ErrorLocation = *pd.Location
+	}
+
+	return ce
+}
@@ -0,0 +1,116 @@
+package errors
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCloudError_Problem(t *testing.T) {
+	timeNow := time.Now().UTC()
+	ce := NewCloudErrorBuilder().
+		StatusCode(409).
+		CustomCode("PresetLocked").
+		CorrelationID("5f1aa5d0-bdb1-4cd7-a807-6d673f49f871").
+		Source("svc-presets").
+		Tags("preset").
+		Message("preset is locked").
+		Build(timeNow)
+
+	When("a cloud error is rendered as a problem", t,
+		Then("the standard members should be derived from the cloud error", func(t *testing.T) {
+			got := ce.Problem()
+			if got.Type != "urn:music-tribe:error:PresetLocked" {
+				t.Errorf("expected type to be built from the custom code but got %s", got.Type)
+			}
+			if got.Title != "Conflict" || got.Status != 409 || got.Detail != "preset is locked" ||
+				got.Instance != ce.CorrelationID {
+				t.Errorf("unexpected problem details %+v", got)
+			}
+		}),
+		And("the remaining fields should be extension members", func(t *testing.T) {
+			got := ce.Problem()
+			if got.Code != "PresetLocked" || got.Source != "svc-presets" || !reflect.DeepEqual(got.Tags, ce.Tags) {
+				t.Errorf("unexpected problem details %+v", got)
+			}
+			if got.Location == nil || got.Location.Method == "" {
+				t.Errorf("expected the location to be included but got %+v", got.Location)
+			}
+		}),
+		And("an empty location should be omitted", func(t *testing.T) {
+			stripped := *ce
+			stripped.ErrorLocation = ErrorLocation{}
+
+			byt, err := json.Marshal(stripped.Problem())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			m := map[string]any{}
+			if err := json.Unmarshal(byt, &m); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := m["location"]; ok {
+				t.Errorf("expected location to be omitted but got %v", m["location"])
+			}
+		}),
+	)
+
+	When("a problem is converted back into a cloud error", t,
+		Then("the cloud error fields should be restored", func(t *testing.T) {
+			got := ce.Problem().CloudError()
+			if got.StatusCode != ce.StatusCode || got.Status != ce.Status || got.Message != ce.Message ||
+				got.CustomCode != ce.CustomCode || got.CorrelationID != ce.CorrelationID || got.Source != ce.Source ||
+				!got.TimeStamp.Equal(ce.TimeStamp) {
+				t.Errorf("ProblemDetails.CloudError() = \n%+v\nwant \n%+v\n", got, ce)
+			}
+		}),
+		And("the custom code should fall back to the type URI", func(t *testing.T) {
+			pd := &ProblemDetails{Type: "urn:music-tribe:error:QuotaExceeded", Status: 429}
+			if got := pd.CloudError(); got.CustomCode != "QuotaExceeded" {
+				t.Errorf("expected custom code to be QuotaExceeded but got %s", got.CustomCode)
+			}
+		}),
+	)
+
+	When("the problem type base URI is changed", t,
+		Then("it should be used by concurrent renders", func(t *testing.T) {
+			SetProblemTypeBaseURI("https://errors.music-tribe.com/")
+			defer SetProblemTypeBaseURI("urn:music-tribe:error:")
+
+			var wg sync.WaitGroup
+			for i := 0; i < 4; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if got := ce.Problem().Type; got != "https://errors.music-tribe.com/PresetLocked" {
+						t.Errorf("unexpected type %s", got)
+					}
+				}()
+			}
+			SetProblemTypeBaseURI("https://errors.music-tribe.com/")
+			wg.Wait()
+		}),
+	)
+}
+
+func TestFromResponse_ProblemJSON(t *testing.T) {
+	ce := NewCloudErrorBuilder().
+		StatusCode(429).
+		CustomCode("QuotaExceeded").
+		CorrelationID("corr-123").
+		Build(time.Now().UTC())
+
+	rec := httptest.NewRecorder()
+	rec.Header().Set("Content-Type", MIMEApplicationProblemJSON)
+	rec.WriteHeader(429)
+	_ = json.NewEncoder(rec).Encode(ce.Problem())
+
+	got := FromResponse(rec.Result())
+	if got.StatusCode != 429 || got.CustomCode != "QuotaExceeded" || got.CorrelationID != "corr-123" {
+		t.Errorf("unexpected cloud error %+v", got)
+	}
+}
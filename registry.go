@@ -0,0 +1,156 @@
+package errors
+
+import (
+	errs "errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrDuplicateCode is returned when a CustomCode is registered more than once.
+var ErrDuplicateCode = errs.New("custom code already registered")
+
+// CodeDefinition describes a CustomCode along with the defaults used when an
+// error is built from that code alone.
+type CodeDefinition struct {
+	Code        CustomCode `json:"code"`
+	StatusCode  int        `json:"status_code"`
+	Message     string     `json:"message"`
+	Description string     `json:"description,omitempty"`
+	Retryable   bool       `json:"retryable"`
+}
+
+// Registry holds the CustomCodes known to a service.
+type Registry struct {
+	mu    sync.RWMutex
+	codes map[CustomCode]CodeDefinition
+}
+
+func NewRegistry() *Registry {
+	return &Registry{codes: map[CustomCode]CodeDefinition{}}
+}
+
+// Register adds the definitions to the registry. It fails without registering
+// anything if a code is empty, has an invalid status code, or has already
+// been registered.
+func (r *Registry) Register(defs ...CodeDefinition) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	seen := map[CustomCode]bool{}
+	for _, def := range defs {
+		if def.Code == "" {
+			return fmt.Errorf("custom code must not be empty")
+		}
+		if def.StatusCode < 100 || def.StatusCode > 599 {
+			return fmt.Errorf("custom code %s has invalid status code %d", def.Code, def.StatusCode)
+		}
+		if _, ok := r.codes[def.Code]; ok || seen[def.Code] {
+			return fmt.Errorf("%w: %s", ErrDuplicateCode, def.Code)
+		}
+		seen[def.Code] = true
+	}
+
+	for _, def := range defs {
+		r.codes[def.Code] = def
+	}
+
+	return nil
+}
+
+// MustRegister is like Register but panics on failure. It is intended to be
+// called from package level variable declarations or init functions.
+func (r *Registry) MustRegister(defs ...CodeDefinition) {
+	if err := r.Register(defs...); err != nil {
+		panic(err)
+	}
+}
+
+func (r *Registry) Lookup(code CustomCode) (CodeDefinition, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	def, ok := r.codes[code]
+	return def, ok
+}
+
+// Codes returns every registered definition, sorted by code.
+func (r *Registry) Codes() []CodeDefinition {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	defs := make([]CodeDefinition, 0, len(r.codes))
+	for _, def := range r.codes {
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Code < defs[j].Code })
+
+	return defs
+}
+
+// DefaultRegistry is the registry used by the package level functions and by
+// cloudErrorBuilder.Build.
+var DefaultRegistry = NewRegistry()
+
+func init() {
+	DefaultRegistry.MustRegister(
+		CodeDefinition{
+			Code:        InternalServerError,
+			StatusCode:  http.StatusInternalServerError,
+			Message:     http.StatusText(http.StatusInternalServerError),
+			Description: "An unexpected error occurred while handling the request.",
+		},
+		CodeDefinition{
+			Code:        NotFound,
+			StatusCode:  http.StatusNotFound,
+			Message:     http.StatusText(http.StatusNotFound),
+			Description: "The requested resource does not exist.",
+		},
+	)
+}
+
+// RegisterCode adds the definitions to the DefaultRegistry.
+func RegisterCode(defs ...CodeDefinition) error {
+	return DefaultRegistry.Register(defs...)
+}
+
+// MustRegisterCode adds the definitions to the DefaultRegistry, panicking on
+// failure.
+func MustRegisterCode(defs ...CodeDefinition) {
+	DefaultRegistry.MustRegister(defs...)
+}
+
+// LookupCode returns the definition of code from the DefaultRegistry.
+func LookupCode(code CustomCode) (CodeDefinition, bool) {
+	return DefaultRegistry.Lookup(code)
+}
+
+// RegisteredCodes returns every definition in the DefaultRegistry, sorted by
+// code.
+func RegisteredCodes() []CodeDefinition {
+	return DefaultRegistry.Codes()
+}
+
+// IsRetryable reports whether err is, or wraps, a CloudError whose CustomCode
+// is registered as retryable.
+func IsRetryable(err error) bool {
+	ce := &CloudError{}
+	if !errs.As(err, &ce) {
+		return false
+	}
+
+	def, ok := LookupCode(ce.CustomCode)
+	return ok && def.Retryable
+}
+
+// NewCloudErrorFromCode builds an error from a registered CustomCode, using
+// its default status code and message.
+func NewCloudErrorFromCode(code CustomCode, options ...CloudErrorOption) *CloudError {
+	se := NewCloudErrorBuilder().
+		CustomCode(code).
+		Build(time.Now().UTC(), options...)
+
+	return se
+}
@@ -0,0 +1,196 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const (
+	testPresetLocked  CustomCode = "TestPresetLocked"
+	testQuotaExceeded CustomCode = "TestQuotaExceeded"
+)
+
+func init() {
+	MustRegisterCode(
+		CodeDefinition{
+			Code:        testPresetLocked,
+			StatusCode:  409,
+			Message:     "the preset is locked by another user",
+			Description: "Returned when a preset is edited while locked.",
+		},
+		CodeDefinition{
+			Code:       testQuotaExceeded,
+			StatusCode: 429,
+			Message:    "quota exceeded",
+			Retryable:  true,
+		},
+	)
+}
+
+func TestRegistry_Register(t *testing.T) {
+	When("a code is registered", t,
+		Then("it should be returned by Lookup", func(t *testing.T) {
+			r := NewRegistry()
+			def := CodeDefinition{Code: "BlobTooLarge", StatusCode: 413, Message: "blob too large"}
+			if err := r.Register(def); err != nil {
+				t.Fatal(err)
+			}
+
+			got, ok := r.Lookup("BlobTooLarge")
+			if !ok || !reflect.DeepEqual(got, def) {
+				t.Errorf("Lookup() = %+v, %v want %+v, true", got, ok, def)
+			}
+		}),
+	)
+
+	When("a code is registered twice", t,
+		Then("it should return ErrDuplicateCode", func(t *testing.T) {
+			r := NewRegistry()
+			def := CodeDefinition{Code: "BlobTooLarge", StatusCode: 413}
+			if err := r.Register(def); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := r.Register(def); !errors.Is(err, ErrDuplicateCode) {
+				t.Errorf("expected ErrDuplicateCode but got %v", err)
+			}
+		}),
+		And("duplicates within one call should be detected and nothing registered", func(t *testing.T) {
+			r := NewRegistry()
+			err := r.Register(
+				CodeDefinition{Code: "BlobMissing", StatusCode: 404},
+				CodeDefinition{Code: "BlobTooLarge", StatusCode: 413},
+				CodeDefinition{Code: "BlobTooLarge", StatusCode: 400},
+			)
+			if !errors.Is(err, ErrDuplicateCode) {
+				t.Errorf("expected ErrDuplicateCode but got %v", err)
+			}
+			if _, ok := r.Lookup("BlobMissing"); ok {
+				t.Errorf("expected no codes to be registered")
+			}
+		}),
+	)
+
+	When("a definition is invalid", t,
+		Then("it should be rejected", func(t *testing.T) {
+			r := NewRegistry()
+			for _, def := range []CodeDefinition{
+				{StatusCode: 400},
+				{Code: "Teapot", StatusCode: 999},
+			} {
+				if err := r.Register(def); err == nil {
+					t.Errorf("expected %+v to be rejected", def)
+				}
+			}
+		}),
+	)
+
+	When("MustRegister fails", t,
+		Then("it should panic", func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected MustRegister to panic")
+				}
+			}()
+
+			r := NewRegistry()
+			r.MustRegister(CodeDefinition{Code: "A", StatusCode: 400}, CodeDefinition{Code: "A", StatusCode: 400})
+		}),
+	)
+}
+
+func TestRegistry_Codes(t *testing.T) {
+	r := NewRegistry()
+	r.MustRegister(
+		CodeDefinition{Code: "Zebra", StatusCode: 400},
+		CodeDefinition{Code: "Alpha", StatusCode: 404},
+		CodeDefinition{Code: "Mango", StatusCode: 409},
+	)
+
+	var got []CustomCode
+	for _, def := range r.Codes() {
+		got = append(got, def.Code)
+	}
+
+	want := []CustomCode{"Alpha", "Mango", "Zebra"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Codes() = %v, want %v", got, want)
+	}
+}
+
+func TestRegisteredCodes(t *testing.T) {
+	codes := map[CustomCode]bool{}
+	for _, def := range RegisteredCodes() {
+		codes[def.Code] = true
+	}
+
+	for _, code := range []CustomCode{InternalServerError, NotFound, testPresetLocked} {
+		if !codes[code] {
+			t.Errorf("expected %s to be registered", code)
+		}
+	}
+}
+
+func TestNewCloudErrorFromCode(t *testing.T) {
+	When("the code is registered", t,
+		Then("the error should use the registered status code and message", func(t *testing.T) {
+			got := NewCloudErrorFromCode(testPresetLocked)
+			if got.StatusCode != 409 || got.Status != "Conflict" || got.CustomCode != testPresetLocked ||
+				got.Message != "the preset is locked by another user" {
+				t.Errorf("unexpected cloud error %+v", got)
+			}
+		}),
+		And("options should still be applied", func(t *testing.T) {
+			got := NewCloudErrorFromCode(testPresetLocked, SetCorrelationIDOption("abc"))
+			if got.CorrelationID != "abc" {
+				t.Errorf("expected correlation id to be abc but got %s", got.CorrelationID)
+			}
+		}),
+	)
+
+	When("the code is not registered", t,
+		Then("the error should fall back to an internal server error", func(t *testing.T) {
+			got := NewCloudErrorFromCode("NeverRegistered")
+			if got.StatusCode != 500 || got.CustomCode != "NeverRegistered" || got.Message != "Internal Server Error" {
+				t.Errorf("unexpected cloud error %+v", got)
+			}
+		}),
+	)
+
+	When("the builder sets a status code and message", t,
+		Then("they should take precedence over the registered defaults", func(t *testing.T) {
+			got := NewCloudErrorBuilder().
+				CustomCode(testPresetLocked).
+				StatusCode(423).
+				Message("locked").
+				Build(time.Now().UTC())
+			if got.StatusCode != 423 || got.Message != "locked" {
+				t.Errorf("unexpected cloud error %+v", got)
+			}
+		}),
+	)
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"retryable code", NewCloudErrorFromCode(testQuotaExceeded), true},
+		{"wrapped retryable code", fmt.Errorf("call: %w", NewCloudErrorFromCode(testQuotaExceeded)), true},
+		{"non retryable code", NewCloudErrorFromCode(testPresetLocked), false},
+		{"unregistered code", NewCloudError(503, "down"), false},
+		{"plain error", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package report
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/music-tribe/errors"
+)
+
+// BatchReporter is a Reporter that can report several errors at once. Async
+// uses ReportBatch when the Reporter it wraps implements it.
+type BatchReporter interface {
+	Reporter
+	ReportBatch(ctx context.Context, ces []*errors.CloudError)
+}
+
+// AsyncConfig configures an Async reporter.
+type AsyncConfig struct {
+	// QueueSize is the number of errors that can wait to be reported. It
+	// defaults to 1024.
+	QueueSize int
+	// BatchSize is the most errors passed on at once. It defaults to 64.
+	BatchSize int
+	// FlushInterval is the longest an error waits for its batch to fill. It
+	// defaults to one second.
+	FlushInterval time.Duration
+	// Block makes Report wait for room in a full queue, until its context is
+	// done, instead of dropping the error.
+	Block bool
+}
+
+// Async is a Reporter that queues errors and passes them on to another
+// Reporter in batches from a background goroutine, so that slow sinks do not
+// hold up responses. Close must be called to flush the queue.
+type Async struct {
+	next Reporter
+	cfg  AsyncConfig
+
+	// mu is held for reading while an error is queued and for writing while
+	// the queue is closed, so that errors are never sent on a closed queue.
+	// closing is closed first, so that Reports waiting for room let go of it.
+	mu        sync.RWMutex
+	closeOnce sync.Once
+	closing   chan struct{}
+	queue     chan *errors.CloudError
+	done      chan struct{}
+
+	// ctx is passed on to the next Reporter, and cancelled when Close gives
+	// up waiting for it.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	dropped atomic.Uint64
+}
+
+// NewAsync starts an Async reporter passing errors on to next.
+func NewAsync(next Reporter, cfg AsyncConfig) *Async {
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1024
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 64
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+
+	a := &Async{
+		next:    next,
+		cfg:     cfg,
+		closing: make(chan struct{}),
+		queue:   make(chan *errors.CloudError, cfg.QueueSize),
+		done:    make(chan struct{}),
+	}
+	a.ctx, a.cancel = context.WithCancel(context.Background())
+	go a.run()
+
+	return a
+}
+
+// Report queues ce. If the queue is full ce is dropped, unless the reporter
+// was configured to block. Errors reported after Close, or while waiting for
+// room when Close is called, are dropped.
+func (a *Async) Report(ctx context.Context, ce *errors.CloudError) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	select {
+	case <-a.closing:
+		a.dropped.Add(1)
+		return
+	default:
+	}
+
+	if !a.cfg.Block {
+		select {
+		case a.queue <- ce:
+		default:
+			a.dropped.Add(1)
+		}
+		return
+	}
+
+	select {
+	case a.queue <- ce:
+	case <-ctx.Done():
+		a.dropped.Add(1)
+	case <-a.closing:
+		a.dropped.Add(1)
+	}
+}
+
+// Dropped returns the number of errors that were dropped rather than queued.
+func (a *Async) Dropped() uint64 {
+	return a.dropped.Load()
+}
+
+// Close stops accepting errors and waits until the queued errors have been
+// passed on, or ctx is done. In that case the context passed to the next
+// Reporter is cancelled, and the errors still queued are abandoned.
+func (a *Async) Close(ctx context.Context) error {
+	a.closeOnce.Do(func() {
+		close(a.closing)
+		go func() {
+			a.mu.Lock()
+			defer a.mu.Unlock()
+			close(a.queue)
+		}()
+	})
+
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		a.cancel()
+		return ctx.Err()
+	}
+}
+
+func (a *Async) run() {
+	defer close(a.done)
+	defer a.cancel()
+
+	ticker := time.NewTicker(a.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*errors.CloudError, 0, a.cfg.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		a.send(batch)
+		batch = make([]*errors.CloudError, 0, a.cfg.BatchSize)
+	}
+
+	for {
+		select {
+		case ce, ok := <-a.queue:
+			if !ok {
+				flush()
+				return
+			}
+
+			batch = append(batch, ce)
+			if len(batch) >= a.cfg.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// send passes a batch on. The requests the errors came from are over by now,
+// so their contexts are not used.
+func (a *Async) send(batch []*errors.CloudError) {
+	ctx := a.ctx
+
+	if br, ok := a.next.(BatchReporter); ok {
+		br.ReportBatch(ctx, batch)
+		return
+	}
+
+	for _, ce := range batch {
+		a.next.Report(ctx, ce)
+	}
+}
@@ -0,0 +1,177 @@
+package report
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/music-tribe/errors"
+)
+
+type batchRecorder struct {
+	recorder
+}
+
+func (r *batchRecorder) ReportBatch(ctx context.Context, ces []*errors.CloudError) {
+	r.mu.Lock()
+	r.batches++
+	r.mu.Unlock()
+
+	for _, ce := range ces {
+		r.Report(ctx, ce)
+	}
+}
+
+// blockingReporter holds up the Async worker until release is closed.
+type blockingReporter struct {
+	recorder
+	release chan struct{}
+}
+
+func (r *blockingReporter) Report(ctx context.Context, ce *errors.CloudError) {
+	<-r.release
+	r.recorder.Report(ctx, ce)
+}
+
+func TestAsync(t *testing.T) {
+	t.Run("when errors are reported they should all be passed on by Close", func(t *testing.T) {
+		rec := &recorder{}
+		a := NewAsync(rec, AsyncConfig{FlushInterval: time.Hour})
+
+		for i := 0; i < 10; i++ {
+			a.Report(context.Background(), errors.NewCloudError(500, "boom"))
+		}
+		if err := a.Close(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := len(rec.reported()); got != 10 {
+			t.Errorf("want 10 errors reported but got %d", got)
+		}
+	})
+
+	t.Run("when the reporter can take batches they should be passed on in batches", func(t *testing.T) {
+		rec := &batchRecorder{}
+		a := NewAsync(rec, AsyncConfig{BatchSize: 4, FlushInterval: time.Hour})
+
+		for i := 0; i < 10; i++ {
+			a.Report(context.Background(), errors.NewCloudError(500, "boom"))
+		}
+		_ = a.Close(context.Background())
+
+		if len(rec.reported()) != 10 || rec.batches != 3 {
+			t.Errorf("want 10 errors in 3 batches but got %d in %d", len(rec.reported()), rec.batches)
+		}
+	})
+
+	t.Run("when the flush interval passes a partial batch should be passed on", func(t *testing.T) {
+		rec := &recorder{}
+		a := NewAsync(rec, AsyncConfig{FlushInterval: 10 * time.Millisecond})
+		defer a.Close(context.Background())
+
+		a.Report(context.Background(), errors.NewCloudError(500, "boom"))
+
+		deadline := time.Now().Add(time.Second)
+		for len(rec.reported()) == 0 && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if len(rec.reported()) != 1 {
+			t.Errorf("want the error to be flushed")
+		}
+	})
+
+	t.Run("when the queue is full errors should be dropped", func(t *testing.T) {
+		rec := &blockingReporter{release: make(chan struct{})}
+		a := NewAsync(rec, AsyncConfig{QueueSize: 1, BatchSize: 1})
+
+		// the first error is taken by the worker, the second fills the queue
+		for i := 0; i < 5; i++ {
+			a.Report(context.Background(), errors.NewCloudError(500, "boom"))
+			time.Sleep(5 * time.Millisecond)
+		}
+		close(rec.release)
+		_ = a.Close(context.Background())
+
+		if got := a.Dropped(); got != 3 || len(rec.reported()) != 2 {
+			t.Errorf("want 3 dropped and 2 reported but got %d and %d", got, len(rec.reported()))
+		}
+	})
+
+	t.Run("when the queue is full and blocking is on Report should wait for its context", func(t *testing.T) {
+		rec := &blockingReporter{release: make(chan struct{})}
+		a := NewAsync(rec, AsyncConfig{QueueSize: 1, BatchSize: 1, Block: true})
+
+		a.Report(context.Background(), errors.NewCloudError(500, "boom"))
+		time.Sleep(5 * time.Millisecond)
+		a.Report(context.Background(), errors.NewCloudError(500, "boom"))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		a.Report(ctx, errors.NewCloudError(500, "boom"))
+		if time.Since(start) < 20*time.Millisecond || a.Dropped() != 1 {
+			t.Errorf("want Report to block until its context was done")
+		}
+
+		close(rec.release)
+		_ = a.Close(context.Background())
+	})
+
+	t.Run("when the sink hangs and Reports are waiting for room Close should still honour its context", func(t *testing.T) {
+		rec := &blockingReporter{release: make(chan struct{})}
+		defer close(rec.release)
+		a := NewAsync(rec, AsyncConfig{QueueSize: 1, BatchSize: 1, Block: true})
+
+		// the first error is taken by the worker, the second fills the queue
+		// and the third waits for room
+		a.Report(context.Background(), errors.NewCloudError(500, "boom"))
+		time.Sleep(5 * time.Millisecond)
+		a.Report(context.Background(), errors.NewCloudError(500, "boom"))
+		waiting := make(chan struct{})
+		go func() {
+			defer close(waiting)
+			a.Report(context.Background(), errors.NewCloudError(500, "boom"))
+		}()
+		time.Sleep(5 * time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		closed := make(chan error, 1)
+		go func() { closed <- a.Close(ctx) }()
+
+		select {
+		case err := <-closed:
+			if err != context.DeadlineExceeded {
+				t.Errorf("want %v but got %v", context.DeadlineExceeded, err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("want Close to give up when its context is done")
+		}
+		select {
+		case <-waiting:
+		case <-time.After(time.Second):
+			t.Fatal("want the waiting Report to be dropped by Close")
+		}
+		if got := a.Dropped(); got != 1 {
+			t.Errorf("want 1 dropped but got %d", got)
+		}
+	})
+
+	t.Run("when errors are reported concurrently with Close it should not panic", func(t *testing.T) {
+		a := NewAsync(&recorder{}, AsyncConfig{})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				a.Report(context.Background(), errors.NewCloudError(500, "boom"))
+			}()
+		}
+		_ = a.Close(context.Background())
+		wg.Wait()
+	})
+}
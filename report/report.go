@@ -0,0 +1,51 @@
+// Package report sends CloudErrors to error reporting sinks such as a logger
+// or Sentry. The handler package calls a Reporter for the errors it writes:
+//
+//	r := report.NewAsync(sentry, report.AsyncConfig{})
+//	defer r.Close(context.Background())
+//	e.HTTPErrorHandler = handler.NewCustomHTTPErrorHandler(handler.WithReporter(r))
+package report
+
+import (
+	"context"
+
+	"github.com/music-tribe/errors"
+)
+
+// Reporter reports a CloudError. Implementations must be safe for concurrent
+// use, and should not hold on to ctx after Report returns.
+type Reporter interface {
+	Report(ctx context.Context, ce *errors.CloudError)
+}
+
+// ReporterFunc is a function that implements Reporter.
+type ReporterFunc func(ctx context.Context, ce *errors.CloudError)
+
+func (fn ReporterFunc) Report(ctx context.Context, ce *errors.CloudError) {
+	fn(ctx, ce)
+}
+
+// StatusRange is an inclusive range of HTTP status codes.
+type StatusRange struct {
+	From, To int
+}
+
+// ServerErrors are the 5xx status codes.
+var ServerErrors = StatusRange{From: 500, To: 599}
+
+func (sr StatusRange) contains(statusCode int) bool {
+	return statusCode >= sr.From && statusCode <= sr.To
+}
+
+// Filter returns a Reporter that only passes errors whose status code is in
+// one of ranges on to r.
+func Filter(r Reporter, ranges ...StatusRange) Reporter {
+	return ReporterFunc(func(ctx context.Context, ce *errors.CloudError) {
+		for _, sr := range ranges {
+			if sr.contains(ce.StatusCode) {
+				r.Report(ctx, ce)
+				return
+			}
+		}
+	})
+}
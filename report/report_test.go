@@ -0,0 +1,97 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/music-tribe/errors"
+)
+
+// recorder is a Reporter that keeps what it is given.
+type recorder struct {
+	mu      sync.Mutex
+	errors  []*errors.CloudError
+	batches int
+}
+
+func (r *recorder) Report(_ context.Context, ce *errors.CloudError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors = append(r.errors, ce)
+}
+
+func (r *recorder) reported() []*errors.CloudError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*errors.CloudError(nil), r.errors...)
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		ranges     []StatusRange
+		statusCode int
+		want       bool
+	}{
+		{name: "when a 5xx error is filtered by the server errors", ranges: []StatusRange{ServerErrors}, statusCode: 503, want: true},
+		{name: "when a 4xx error is filtered by the server errors", ranges: []StatusRange{ServerErrors}, statusCode: 404, want: false},
+		{name: "when an error is in the second of several ranges", ranges: []StatusRange{ServerErrors, {From: 429, To: 429}}, statusCode: 429, want: true},
+		{name: "when there are no ranges", statusCode: 500, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recorder{}
+			Filter(rec, tt.ranges...).Report(context.Background(), errors.NewCloudError(tt.statusCode, "boom"))
+
+			if got := len(rec.reported()) == 1; got != tt.want {
+				t.Errorf("want reported to be %v but got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSlog_LogRedactor(t *testing.T) {
+	errors.SetLogRedactor(&errors.Redactor{Rules: []errors.RedactionRule{errors.SecretRule}})
+	t.Cleanup(func() { errors.SetLogRedactor(nil) })
+
+	t.Run("when a log redactor is set the record message should be redacted", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		NewSlog(slog.New(slog.NewJSONHandler(buf, nil))).Report(context.Background(), errors.NewCloudError(500, "dial postgres://bob:hunter2@db failed"))
+
+		if bytes.Contains(buf.Bytes(), []byte("hunter2")) {
+			t.Errorf("want the secret to be redacted but got %s", buf.String())
+		}
+	})
+}
+
+func TestSlog(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		wantLevel  string
+	}{
+		{name: "when a 5xx error is reported it should be logged as an error", statusCode: 500, wantLevel: "ERROR"},
+		{name: "when a 4xx error is reported it should be logged as a warning", statusCode: 409, wantLevel: "WARN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			NewSlog(slog.New(slog.NewJSONHandler(buf, nil))).Report(context.Background(), errors.NewCloudError(tt.statusCode, "boom"))
+
+			rec := map[string]any{}
+			if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+				t.Fatal(err)
+			}
+			if rec["level"] != tt.wantLevel || rec["msg"] != "boom" {
+				t.Errorf("unexpected record %v", rec)
+			}
+			if ce, _ := rec["error"].(map[string]any); ce["status_code"] != float64(tt.statusCode) {
+				t.Errorf("want the error to be logged as a group but got %v", rec["error"])
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/music-tribe/errors"
+	"github.com/music-tribe/uuid"
+)
+
+const (
+	sentryClient         = "music-tribe-errors/1.0"
+	defaultSentryTimeout = 10 * time.Second
+)
+
+// SentryConfig configures a Sentry reporter.
+type SentryConfig struct {
+	// DSN is the project's client key, e.g.
+	// https://public@o0.ingest.sentry.io/1234.
+	DSN         string
+	Environment string
+	Release     string
+	// Client sends the events. It defaults to a client with a 10 second
+	// timeout, so that an unresponsive endpoint cannot hold up the reporter.
+	Client *http.Client
+	// OnError is called when an event cannot be sent.
+	OnError func(error)
+}
+
+// Sentry is a Reporter that sends errors to Sentry, or any service that
+// accepts Sentry envelopes, one request per error. Wrap it with NewAsync to
+// keep the requests off the request path.
+type Sentry struct {
+	cfg      SentryConfig
+	endpoint string
+	auth     string
+}
+
+// NewSentry returns a Sentry reporter for the DSN in cfg.
+func NewSentry(cfg SentryConfig) (*Sentry, error) {
+	u, err := url.Parse(cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("parsing sentry dsn: %w", err)
+	}
+
+	key := u.User.Username()
+	project := path.Base(u.Path)
+	if key == "" || project == "" || project == "." || project == "/" {
+		return nil, fmt.Errorf("invalid sentry dsn %q", cfg.DSN)
+	}
+
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: defaultSentryTimeout}
+	}
+
+	endpoint := url.URL{
+		Scheme: u.Scheme,
+		Host:   u.Host,
+		Path:   path.Join(path.Dir(u.Path), "api", project, "envelope") + "/",
+	}
+
+	return &Sentry{
+		cfg:      cfg,
+		endpoint: endpoint.String(),
+		auth:     fmt.Sprintf("Sentry sentry_version=7, sentry_client=%s, sentry_key=%s", sentryClient, key),
+	}, nil
+}
+
+func (s *Sentry) Report(ctx context.Context, ce *errors.CloudError) {
+	if err := s.send(ctx, ce); err != nil && s.cfg.OnError != nil {
+		s.cfg.OnError(err)
+	}
+}
+
+func (s *Sentry) send(ctx context.Context, ce *errors.CloudError) error {
+	event := s.event(ce)
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	_ = enc.Encode(map[string]string{
+		"event_id": event.EventID,
+		"sent_at":  time.Now().UTC().Format(time.RFC3339Nano),
+		"dsn":      s.cfg.DSN,
+	})
+	_ = enc.Encode(map[string]any{
+		"type":         "event",
+		"length":       len(payload),
+		"content_type": "application/json",
+	})
+	body.Write(payload)
+	body.WriteByte('\n')
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", s.auth)
+
+	resp, err := s.cfg.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending sentry event: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sending sentry event: %s", resp.Status)
+	}
+	return nil
+}
+
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   time.Time         `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	Exception   sentryExceptions  `json:"exception"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Extra       map[string]any    `json:"extra,omitempty"`
+	User        *sentryUser       `json:"user,omitempty"`
+}
+
+type sentryExceptions struct {
+	Values []sentryException `json:"values"`
+}
+
+type sentryException struct {
+	Type       string            `json:"type"`
+	Value      string            `json:"value"`
+	Module     string            `json:"module,omitempty"`
+	Stacktrace *sentryStacktrace `json:"stacktrace,omitempty"`
+}
+
+type sentryStacktrace struct {
+	Frames []sentryFrame `json:"frames"`
+}
+
+type sentryFrame struct {
+	Function string `json:"function"`
+	AbsPath  string `json:"abs_path,omitempty"`
+	Lineno   int    `json:"lineno,omitempty"`
+}
+
+type sentryUser struct {
+	ID string `json:"id"`
+}
+
+func (s *Sentry) event(ce *errors.CloudError) sentryEvent {
+	value := ce.Message
+	if ce.InternalMessage != "" && ce.InternalMessage != ce.Message {
+		value += ": " + ce.InternalMessage
+	}
+
+	event := sentryEvent{
+		EventID:     strings.ReplaceAll(uuid.New().String(), "-", ""),
+		Timestamp:   ce.TimeStamp,
+		Level:       "warning",
+		Platform:    "go",
+		ServerName:  ce.ErrorLocation.Service,
+		Environment: s.cfg.Environment,
+		Release:     s.cfg.Release,
+		Exception: sentryExceptions{Values: []sentryException{{
+			Type:       string(ce.CustomCode),
+			Value:      value,
+			Module:     ce.ErrorLocation.Package,
+			Stacktrace: stacktrace(ce),
+		}}},
+		Tags: map[string]string{
+			"status_code": strconv.Itoa(ce.StatusCode),
+			"custom_code": string(ce.CustomCode),
+			"source":      ce.Source,
+		},
+	}
+	if ce.StatusCode >= 500 {
+		event.Level = "error"
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+	if ce.CorrelationID != "" {
+		event.Tags["correlation_id"] = ce.CorrelationID
+	}
+	if ce.TenantID != "" {
+		event.Tags["tenant_id"] = ce.TenantID
+	}
+	if ce.UserID != "" {
+		event.User = &sentryUser{ID: ce.UserID}
+	}
+
+	extra := map[string]any{}
+	if len(ce.Tags) > 0 {
+		extra["tags"] = ce.Tags
+	}
+	if ce.InternalError != nil {
+		extra["cause"] = ce.InternalError.Error()
+	}
+	if len(extra) > 0 {
+		event.Extra = extra
+	}
+
+	return event
+}
+
+// stacktrace returns the error's stack, or its location if no stack was
+// captured. Sentry expects the outermost frame first.
+func stacktrace(ce *errors.CloudError) *sentryStacktrace {
+	frames := ce.StackTrace()
+	if len(frames) == 0 {
+		if ce.ErrorLocation.Method == "" {
+			return nil
+		}
+
+		return &sentryStacktrace{Frames: []sentryFrame{{
+			Function: ce.ErrorLocation.Method,
+			AbsPath:  ce.ErrorLocation.Page,
+			Lineno:   ce.ErrorLocation.Line,
+		}}}
+	}
+
+	st := &sentryStacktrace{Frames: make([]sentryFrame, len(frames))}
+	for i, frame := range frames {
+		st.Frames[len(frames)-1-i] = sentryFrame{
+			Function: frame.Function,
+			AbsPath:  frame.File,
+			Lineno:   frame.Line,
+		}
+	}
+
+	return st
+}
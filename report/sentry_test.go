@@ -0,0 +1,144 @@
+package report
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/music-tribe/errors"
+)
+
+type envelope struct {
+	path   string
+	auth   string
+	header map[string]any
+	item   map[string]any
+	event  sentryEvent
+}
+
+func newTestSentryServer(t *testing.T, status int) (*httptest.Server, chan envelope) {
+	t.Helper()
+
+	got := make(chan envelope, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		env := envelope{path: r.URL.Path, auth: r.Header.Get("X-Sentry-Auth")}
+
+		sc := bufio.NewScanner(r.Body)
+		sc.Buffer(nil, 1<<20)
+		for i, v := range []any{&env.header, &env.item, &env.event} {
+			if !sc.Scan() {
+				t.Errorf("envelope is missing line %d", i)
+				break
+			}
+			if err := json.Unmarshal(sc.Bytes(), v); err != nil {
+				t.Errorf("line %d: %v", i, err)
+			}
+		}
+		_, _ = io.Copy(io.Discard, r.Body)
+
+		w.WriteHeader(status)
+		got <- env
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, got
+}
+
+func TestNewSentry(t *testing.T) {
+	tests := []struct {
+		name         string
+		dsn          string
+		wantEndpoint string
+		wantErr      bool
+	}{
+		{name: "when the dsn is valid", dsn: "https://key@o1.ingest.sentry.io/42", wantEndpoint: "https://o1.ingest.sentry.io/api/42/envelope/"},
+		{name: "when the dsn has a path prefix", dsn: "http://key@localhost:9000/sentry/42", wantEndpoint: "http://localhost:9000/sentry/api/42/envelope/"},
+		{name: "when the dsn has no key", dsn: "https://o1.ingest.sentry.io/42", wantErr: true},
+		{name: "when the dsn has no project", dsn: "https://key@o1.ingest.sentry.io/", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewSentry(SentryConfig{DSN: tt.dsn})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("want error %v but got %v", tt.wantErr, err)
+			}
+			if err == nil && got.endpoint != tt.wantEndpoint {
+				t.Errorf("want endpoint %s but got %s", tt.wantEndpoint, got.endpoint)
+			}
+			if err == nil && got.cfg.Client.Timeout == 0 {
+				t.Errorf("want the default client to have a timeout")
+			}
+		})
+	}
+}
+
+func TestSentry_Report(t *testing.T) {
+	t.Run("when an error is reported it should be sent as an envelope", func(t *testing.T) {
+		srv, got := newTestSentryServer(t, 200)
+		dsn := strings.Replace(srv.URL, "://", "://key@", 1) + "/42"
+
+		s, err := NewSentry(SentryConfig{DSN: dsn, Environment: "test"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ce := errors.NewCloudError(503, "database unavailable", errors.CaptureStackOption(), errors.SetCorrelationIDOption("corr-1"))
+		s.Report(context.Background(), ce)
+		env := <-got
+
+		if env.path != "/api/42/envelope/" || !strings.Contains(env.auth, "sentry_key=key") {
+			t.Errorf("unexpected request to %s with auth %s", env.path, env.auth)
+		}
+		if env.header["event_id"] != env.event.EventID || env.item["type"] != "event" {
+			t.Errorf("unexpected envelope headers %v %v", env.header, env.item)
+		}
+
+		ev := env.event
+		if ev.Level != "error" || ev.Environment != "test" || ev.Tags["correlation_id"] != "corr-1" || ev.Tags["status_code"] != "503" {
+			t.Errorf("unexpected event %+v", ev)
+		}
+
+		exc := ev.Exception.Values[0]
+		if exc.Type != "ServiceUnavailable" || exc.Value != "database unavailable" {
+			t.Errorf("unexpected exception %+v", exc)
+		}
+
+		frames := exc.Stacktrace.Frames
+		if last := frames[len(frames)-1]; last.Function != ce.StackTrace()[0].Function {
+			t.Errorf("want the innermost frame last but got %+v", last)
+		}
+	})
+
+	t.Run("when the error has an internal message it should be in the exception", func(t *testing.T) {
+		srv, got := newTestSentryServer(t, 200)
+		s, err := NewSentry(SentryConfig{DSN: strings.Replace(srv.URL, "://", "://key@", 1) + "/42"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		s.Report(context.Background(), errors.NewCloudError(500, fmt.Errorf("dial tcp: connection refused")))
+		if exc := (<-got).event.Exception.Values[0]; exc.Value != "Internal Server Error: dial tcp: connection refused" {
+			t.Errorf("unexpected exception value %q", exc.Value)
+		}
+	})
+
+	t.Run("when the event is rejected OnError should be called", func(t *testing.T) {
+		srv, got := newTestSentryServer(t, 429)
+		dsn := strings.Replace(srv.URL, "://", "://key@", 1) + "/42"
+
+		var reportErr error
+		s, _ := NewSentry(SentryConfig{DSN: dsn, OnError: func(err error) { reportErr = err }})
+		s.Report(context.Background(), errors.NewCloudError(500, "boom"))
+		<-got
+
+		if reportErr == nil || !strings.Contains(reportErr.Error(), "429") {
+			t.Errorf("want a 429 error but got %v", reportErr)
+		}
+	})
+}
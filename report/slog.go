@@ -0,0 +1,34 @@
+package report
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/music-tribe/errors"
+)
+
+// Slog is a Reporter that logs errors, 5xx at error level and anything else at
+// warn level.
+type Slog struct {
+	logger *slog.Logger
+}
+
+// NewSlog returns a Reporter logging to logger, or slog.Default if nil.
+func NewSlog(logger *slog.Logger) *Slog {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &Slog{logger: logger}
+}
+
+func (s *Slog) Report(ctx context.Context, ce *errors.CloudError) {
+	level := slog.LevelWarn
+	if ce.StatusCode >= 500 {
+		level = slog.LevelError
+	}
+
+	// the error group is redacted when logged, but the record message is not
+	msg := errors.LogRedactor().CloudError(ce).Message
+	s.logger.LogAttrs(ctx, level, msg, slog.Any("error", ce))
+}
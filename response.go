@@ -0,0 +1,116 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxResponseBodySize = 1 << 20
+	maxSynthesizedMsg   = 512
+)
+
+// FromResponse turns an error response from another service back into a
+// CloudError. Bodies in the CloudError JSON or Problem Details format are
+// decoded as is, anything else is synthesized into a CloudError carrying the
+// response status. It returns nil for responses with a status code below 400.
+//
+// The body is read, and replaced with an in-memory copy so that it can still
+// be read by the caller.
+func FromResponse(resp *http.Response) *CloudError {
+	if resp == nil || resp.StatusCode < 400 {
+		return nil
+	}
+
+	var body []byte
+	if resp.Body != nil {
+		body, _ = io.ReadAll(io.LimitReader(resp.Body, maxResponseBodySize))
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	if ce := decodeCloudError(resp, body); ce != nil {
+		return ce
+	}
+
+	builder := NewCloudErrorBuilder().
+		StatusCode(resp.StatusCode).
+		Message(synthesizedMessage(body)).
+		CorrelationID(CorrelationIDFromHeader(resp.Header))
+	if resp.Request != nil && resp.Request.URL != nil {
+		builder.Source(resp.Request.URL.Host)
+	}
+
+	return builder.Build(time.Now().UTC())
+}
+
+func decodeCloudError(resp *http.Response, body []byte) *CloudError {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+
+	var ce *CloudError
+	switch mediaType {
+	case MIMEApplicationProblemJSON:
+		pd := new(ProblemDetails)
+		if err := json.Unmarshal(body, pd); err != nil {
+			return nil
+		}
+		ce = pd.CloudError()
+	case "", "application/json":
+		ce = new(CloudError)
+		if err := json.Unmarshal(body, ce); err != nil {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	if ce.StatusCode == 0 || ce.CustomCode == "" {
+		return nil
+	}
+
+	return ce
+}
+
+func synthesizedMessage(body []byte) string {
+	msg := strings.TrimSpace(string(body))
+	if len(msg) > maxSynthesizedMsg {
+		msg = msg[:maxSynthesizedMsg]
+		for !utf8.ValidString(msg) {
+			msg = msg[:len(msg)-1]
+		}
+	}
+
+	return msg
+}
+
+// Do sends req with client, or http.DefaultClient if client is nil, and
+// converts an error response into a CloudError using FromResponse. When the
+// response has a status code of 400 or above, its body is closed and the
+// CloudError is returned as the error, so callers can recover it with
+// errors.As. Errors from the client itself are returned as they are.
+//
+// This is done here rather than in an http.RoundTripper, as round trippers
+// must not return an error for a response they obtained.
+func Do(client *http.Client, req *http.Request) (*http.Response, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if ce := FromResponse(resp); ce != nil {
+		resp.Body.Close()
+		return nil, ce
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,161 @@
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFromResponse(t *testing.T) {
+	When("the response is successful", t,
+		Then("it should return nil", func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			rec.WriteHeader(http.StatusOK)
+
+			if got := FromResponse(rec.Result()); got != nil {
+				t.Errorf("expected nil but got %v", got)
+			}
+		}),
+	)
+
+	When("the response body is a cloud error", t,
+		Then("the cloud error should be rebuilt with its fields intact", func(t *testing.T) {
+			var code CustomCode = "PresetLocked"
+			want := NewCloudErrorBuilder().
+				StatusCode(409).
+				CustomCode(code).
+				CorrelationID("5f1aa5d0-bdb1-4cd7-a807-6d673f49f871").
+				Source("svc-presets").
+				Message("preset is locked").
+				Build(time.Now().UTC())
+
+			rec := httptest.NewRecorder()
+			rec.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			rec.WriteHeader(409)
+			_ = json.NewEncoder(rec).Encode(want)
+
+			got := FromResponse(rec.Result())
+			if got == nil {
+				t.Fatalf("expected a cloud error")
+			}
+			if got.StatusCode != 409 || got.CustomCode != code || got.CorrelationID != want.CorrelationID ||
+				got.Source != "svc-presets" || got.Message != "preset is locked" {
+				t.Errorf("FromResponse() = \n%+v\nwant \n%+v\n", got, want)
+			}
+		}),
+		And("the body should still be readable", func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			rec.WriteHeader(404)
+			_ = json.NewEncoder(rec).Encode(NewCloudError(404, "gone"))
+
+			resp := rec.Result()
+			_ = FromResponse(resp)
+
+			byt, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(byt), `"message":"gone"`) {
+				t.Errorf("expected body to be readable after decoding but got %s", byt)
+			}
+		}),
+	)
+
+	When("the response body is not a cloud error", t,
+		Then("a cloud error should be synthesized from the status and body", func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			rec.Header().Set("Content-Type", "text/plain")
+			rec.Header().Set(HeaderRequestID, "5f1aa5d0-bdb1-4cd7-a807-6d673f49f871")
+			rec.WriteHeader(502)
+			_, _ = rec.WriteString("upstream connect error\n")
+
+			resp := rec.Result()
+			resp.Request = httptest.NewRequest("GET", "http://svc-users.internal/users/1", nil)
+
+			got := FromResponse(resp)
+			if got.StatusCode != 502 || got.CustomCode != "BadGateway" {
+				t.Errorf("expected a 502 BadGateway error but got %d %s", got.StatusCode, got.CustomCode)
+			}
+			if got.Message != "upstream connect error" {
+				t.Errorf("expected message to be the response body but got %q", got.Message)
+			}
+			if got.Source != "svc-users.internal" {
+				t.Errorf("expected source to be the request host but got %s", got.Source)
+			}
+			if got.CorrelationID != "5f1aa5d0-bdb1-4cd7-a807-6d673f49f871" {
+				t.Errorf("expected correlation ID to be taken from the headers but got %q", got.CorrelationID)
+			}
+		}),
+		And("JSON bodies in another shape should be synthesized too", func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			rec.Header().Set("Content-Type", "application/json")
+			rec.WriteHeader(401)
+			_, _ = rec.WriteString(`{"error":"token expired"}`)
+
+			got := FromResponse(rec.Result())
+			if got.StatusCode != 401 || got.CustomCode != "Unauthorized" {
+				t.Errorf("expected a 401 Unauthorized error but got %d %s", got.StatusCode, got.CustomCode)
+			}
+		}),
+		And("an empty body should default the message to the status", func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			rec.WriteHeader(503)
+
+			if got := FromResponse(rec.Result()); got.Message != http.StatusText(503) {
+				t.Errorf("expected message to be %s but got %s", http.StatusText(503), got.Message)
+			}
+		}),
+	)
+}
+
+func TestDo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ok" {
+			_, _ = w.Write([]byte("ok"))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(404)
+		ce := NewCloudErrorBuilder().StatusCode(404).CorrelationID(r.Header.Get("X-Request-ID")).Build(time.Now().UTC())
+		_ = json.NewEncoder(w).Encode(ce)
+	}))
+	defer srv.Close()
+
+	When("the server responds successfully", t,
+		Then("the response should be returned untouched", func(t *testing.T) {
+			req, _ := http.NewRequest("GET", srv.URL+"/ok", nil)
+			resp, err := Do(nil, req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != 200 {
+				t.Errorf("expected status code to be 200 but got %d", resp.StatusCode)
+			}
+		}),
+	)
+
+	When("the server responds with a cloud error", t,
+		Then("the client should return that cloud error", func(t *testing.T) {
+			req, _ := http.NewRequest("GET", srv.URL+"/missing", nil)
+			req.Header.Set("X-Request-ID", "corr-123")
+
+			_, err := Do(&http.Client{Transport: &CorrelationTransport{}}, req)
+
+			var ce *CloudError
+			if !errors.As(err, &ce) {
+				t.Fatalf("expected a *CloudError but got %v", err)
+			}
+			if ce.StatusCode != 404 || ce.CustomCode != NotFound || ce.CorrelationID != "corr-123" {
+				t.Errorf("unexpected cloud error %+v", ce)
+			}
+		}),
+	)
+}
@@ -0,0 +1,188 @@
+package errors
+
+import (
+	"context"
+	errs "errors"
+	"log/slog"
+	"strconv"
+)
+
+// LogValue implements slog.LogValuer so that a CloudError is logged as a
+// single structured group rather than as its JSON representation. The
+// redactor set with SetLogRedactor is applied first.
+func (se *CloudError) LogValue() slog.Value {
+	se = logRedactor.Load().CloudError(se)
+
+	attrs := []slog.Attr{
+		slog.Int("status_code", se.StatusCode),
+		slog.String("custom_code", string(se.CustomCode)),
+		slog.String("message", se.Message),
+	}
+
+	if se.InternalMessage != "" {
+		attrs = append(attrs, slog.String("internal_message", se.InternalMessage))
+	}
+	if se.MessageKey != "" {
+		attrs = append(attrs, slog.String("message_key", se.MessageKey))
+	}
+	if se.Source != "" {
+		attrs = append(attrs, slog.String("source", se.Source))
+	}
+	if se.CorrelationID != "" {
+		attrs = append(attrs, slog.String("correlation_id", se.CorrelationID))
+	}
+	if se.UserID != "" {
+		attrs = append(attrs, slog.String("user_id", se.UserID))
+	}
+	if se.TenantID != "" {
+		attrs = append(attrs, slog.String("tenant_id", se.TenantID))
+	}
+	if len(se.Tags) > 0 {
+		attrs = append(attrs, slog.Any("tags", se.Tags))
+	}
+	if len(se.FieldErrors) > 0 {
+		attrs = append(attrs, slog.Any("field_errors", se.FieldErrors))
+	}
+	if se.Details != nil {
+		attrs = append(attrs, slog.Any("details", se.Details))
+	}
+	if len(se.Items) > 0 {
+		items := make([]slog.Attr, len(se.Items))
+		for i, item := range se.Items {
+			items[i] = slog.Any(strconv.Itoa(item.Index), item.Error)
+		}
+		attrs = append(attrs, slog.Attr{Key: "items", Value: slog.GroupValue(items...)})
+	}
+	if loc := se.ErrorLocation.logAttrs(); len(loc) > 0 {
+		attrs = append(attrs, slog.Attr{Key: "location", Value: slog.GroupValue(loc...)})
+	}
+	if se.InternalError != nil {
+		attrs = append(attrs, causeAttr(se.InternalError))
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+func (l ErrorLocation) logAttrs() []slog.Attr {
+	var attrs []slog.Attr
+	if l.Service != "" {
+		attrs = append(attrs, slog.String("service", l.Service))
+	}
+	if l.Package != "" {
+		attrs = append(attrs, slog.String("package", l.Package))
+	}
+	if l.Function != "" {
+		attrs = append(attrs, slog.String("function", l.Function))
+	}
+	if l.File != "" {
+		attrs = append(attrs, slog.String("file", l.File))
+	}
+	if l.Line != 0 {
+		attrs = append(attrs, slog.Int("line", l.Line))
+	}
+
+	return attrs
+}
+
+// causeAttr logs a nested CloudError as a group and anything else by its
+// message.
+func causeAttr(err error) slog.Attr {
+	ce := &CloudError{}
+	if errs.As(err, &ce) {
+		return slog.Any("cause", ce)
+	}
+
+	return slog.String("cause", err.Error())
+}
+
+// NewSlogHandler wraps next so that any error attribute wrapping a CloudError
+// is logged as the CloudError's structured group, and the correlation ID of
+// the first CloudError is added to the record if it has none.
+func NewSlogHandler(next slog.Handler) slog.Handler {
+	return &slogHandler{next: next}
+}
+
+type slogHandler struct {
+	next slog.Handler
+}
+
+func (h *slogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	var (
+		found          bool
+		correlationID  string
+		hasCorrelation bool
+	)
+
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == "correlation_id" {
+			hasCorrelation = true
+		}
+
+		var ce *CloudError
+		if a, ce = enrichAttr(a); ce != nil {
+			found = true
+			if correlationID == "" {
+				correlationID = ce.CorrelationID
+			}
+		}
+
+		attrs = append(attrs, a)
+		return true
+	})
+
+	if !found {
+		return h.next.Handle(ctx, r)
+	}
+
+	nr := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	nr.AddAttrs(attrs...)
+	if correlationID != "" && !hasCorrelation {
+		nr.AddAttrs(slog.String("correlation_id", correlationID))
+	}
+
+	return h.next.Handle(ctx, nr)
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	enriched := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		enriched[i], _ = enrichAttr(a)
+	}
+
+	return &slogHandler{next: h.next.WithAttrs(enriched)}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	return &slogHandler{next: h.next.WithGroup(name)}
+}
+
+// enrichAttr returns the CloudError carried by a, if any, along with a
+// replacement attribute that logs it structurally. Errors that merely wrap a
+// CloudError keep their own message under "error".
+func enrichAttr(a slog.Attr) (slog.Attr, *CloudError) {
+	if k := a.Value.Kind(); k != slog.KindAny && k != slog.KindLogValuer {
+		return a, nil
+	}
+
+	err, ok := a.Value.Any().(error)
+	if !ok {
+		return a, nil
+	}
+
+	ce := &CloudError{}
+	if !errs.As(err, &ce) {
+		return a, nil
+	}
+	if err == error(ce) {
+		return a, ce
+	}
+
+	// the wrapper's text repeats the CloudError's, so it is redacted the same
+	attrs := append(ce.LogValue().Group(), slog.String("error", LogRedactor().String(err.Error())))
+	return slog.Attr{Key: a.Key, Value: slog.GroupValue(attrs...)}, ce
+}
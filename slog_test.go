@@ -0,0 +1,157 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func newTestLogger(wrap bool) (*slog.Logger, *bytes.Buffer) {
+	buf := new(bytes.Buffer)
+	var h slog.Handler = slog.NewJSONHandler(buf, nil)
+	if wrap {
+		h = NewSlogHandler(h)
+	}
+
+	return slog.New(h), buf
+}
+
+func decodeLogLine(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+
+	if n := strings.Count(strings.TrimSpace(buf.String()), "\n"); n != 0 {
+		t.Fatalf("expected a single log line but got %q", buf.String())
+	}
+
+	rec := map[string]any{}
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+
+	return rec
+}
+
+func TestCloudError_LogValue(t *testing.T) {
+	ce := NewCloudError(404, "preset not found",
+		SetCorrelationIDOption("corr-1"),
+		func(se *CloudError) { se.Tags = []string{"presets"} },
+	)
+	ce.InternalError = fmt.Errorf("lookup failed")
+
+	When("a cloud error is logged", t,
+		Then("it should be a single structured group", func(t *testing.T) {
+			logger, buf := newTestLogger(false)
+			logger.Error("request failed", "err", ce)
+
+			rec := decodeLogLine(t, buf)
+			got, ok := rec["err"].(map[string]any)
+			if !ok {
+				t.Fatalf("expected err to be a group but got %v", rec["err"])
+			}
+			if got["status_code"] != float64(404) || got["custom_code"] != string(NotFound) ||
+				got["correlation_id"] != "corr-1" || got["cause"] != "lookup failed" {
+				t.Errorf("unexpected group %v", got)
+			}
+			if tags, _ := got["tags"].([]any); len(tags) != 1 || tags[0] != "presets" {
+				t.Errorf("expected tags [presets] but got %v", got["tags"])
+			}
+			if loc, _ := got["location"].(map[string]any); loc["function"] == nil || loc["line"] == nil {
+				t.Errorf("expected the location group but got %v", got["location"])
+			}
+		}),
+	)
+
+	When("the cause is another cloud error", t,
+		Then("the cause should be logged as a nested group", func(t *testing.T) {
+			outer := NewCloudError(502, "upstream failed")
+			outer.InternalError = NewCloudError(404, "missing")
+
+			logger, buf := newTestLogger(false)
+			logger.Error("request failed", "err", outer)
+
+			rec := decodeLogLine(t, buf)
+			cause, _ := rec["err"].(map[string]any)["cause"].(map[string]any)
+			if cause["status_code"] != float64(404) {
+				t.Errorf("expected a nested cause group but got %v", rec["err"])
+			}
+		}),
+	)
+}
+
+func TestSlogHandler(t *testing.T) {
+	ce := NewCloudError(404, "preset not found", SetCorrelationIDOption("corr-1"))
+
+	When("an error wrapping a cloud error is logged", t,
+		Then("it should be logged as the cloud error's group", func(t *testing.T) {
+			logger, buf := newTestLogger(true)
+			logger.Error("request failed", "err", fmt.Errorf("loading preset: %w", ce))
+
+			rec := decodeLogLine(t, buf)
+			got, ok := rec["err"].(map[string]any)
+			if !ok {
+				t.Fatalf("expected err to be a group but got %v", rec["err"])
+			}
+			if got["custom_code"] != string(NotFound) || !strings.HasPrefix(got["error"].(string), "loading preset: ") {
+				t.Errorf("unexpected group %v", got)
+			}
+		}),
+		And("the correlation ID should be added to the record", func(t *testing.T) {
+			logger, buf := newTestLogger(true)
+			logger.Error("request failed", "err", ce)
+
+			if rec := decodeLogLine(t, buf); rec["correlation_id"] != "corr-1" {
+				t.Errorf("expected correlation_id corr-1 but got %v", rec["correlation_id"])
+			}
+		}),
+	)
+
+	When("the record already has a correlation ID", t,
+		Then("it should not be overwritten", func(t *testing.T) {
+			logger, buf := newTestLogger(true)
+			logger.Error("request failed", "correlation_id", "mine", "err", ce)
+
+			if got := strings.Count(buf.String(), `"correlation_id":"mine"`); got != 1 || strings.Contains(buf.String(), `,"correlation_id":"corr-1"}`) {
+				t.Errorf("expected only the record's correlation ID at the top level but got %s", buf.String())
+			}
+		}),
+	)
+
+	When("the cloud error is attached with With", t,
+		Then("it should still be logged structurally", func(t *testing.T) {
+			logger, buf := newTestLogger(true)
+			logger.With("err", fmt.Errorf("wrapped: %w", ce)).Info("done")
+
+			if _, ok := decodeLogLine(t, buf)["err"].(map[string]any); !ok {
+				t.Errorf("expected err to be a group but got %s", buf.String())
+			}
+		}),
+	)
+
+	When("a log redactor is set", t,
+		Then("the text of the wrapping error should be redacted too", func(t *testing.T) {
+			SetLogRedactor(&Redactor{Rules: []RedactionRule{SecretRule}})
+			t.Cleanup(func() { SetLogRedactor(nil) })
+
+			logger, buf := newTestLogger(true)
+			logger.Error("request failed", "err", fmt.Errorf("wrap: %w", NewCloudError(500, fmt.Errorf("connect (password=hunter2)"))))
+
+			if strings.Contains(buf.String(), "hunter2") {
+				t.Errorf("expected the secret to be redacted in %s", buf.String())
+			}
+		}),
+	)
+
+	When("no cloud error is logged", t,
+		Then("the record should pass through unchanged", func(t *testing.T) {
+			logger, buf := newTestLogger(true)
+			logger.Info("hello", "n", 1)
+
+			if rec := decodeLogLine(t, buf); rec["n"] != float64(1) || rec["correlation_id"] != nil {
+				t.Errorf("unexpected record %v", rec)
+			}
+		}),
+	)
+}
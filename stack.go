@@ -0,0 +1,101 @@
+package errors
+
+import (
+	"encoding/json"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+const maxStackDepth = 64
+
+var captureStacks atomic.Bool
+
+// SetCaptureStacks turns full stack trace capture on or off for every error
+// built from now on. It is off by default, and can also be enabled per error
+// with the builder's CaptureStack method or CaptureStackOption.
+func SetCaptureStacks(enabled bool) {
+	captureStacks.Store(enabled)
+}
+
+// StackFrame is a single symbolised frame of a Stack.
+type StackFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// Stack is the call stack of the place an error was built. Only program
+// counters are recorded when the error is built; they are symbolised the
+// first time the frames are needed.
+type Stack struct {
+	pcs []uintptr
+
+	once   sync.Once
+	frames []StackFrame
+}
+
+func callers(skip int) *Stack {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(skip+1, pcs)
+
+	return &Stack{pcs: pcs[:n]}
+}
+
+// Frames returns the symbolised frames of the stack, innermost first.
+func (s *Stack) Frames() []StackFrame {
+	if s == nil {
+		return nil
+	}
+
+	s.once.Do(func() {
+		if len(s.pcs) == 0 {
+			return
+		}
+
+		frames := runtime.CallersFrames(s.pcs)
+		for {
+			frame, more := frames.Next()
+			s.frames = append(s.frames, StackFrame{
+				Function: frame.Function,
+				File:     frame.File,
+				Line:     frame.Line,
+			})
+			if !more {
+				break
+			}
+		}
+	})
+
+	return s.frames
+}
+
+func (s *Stack) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Frames())
+}
+
+// UnmarshalJSON decodes frames that were symbolised by another process.
+func (s *Stack) UnmarshalJSON(data []byte) error {
+	var frames []StackFrame
+	if err := json.Unmarshal(data, &frames); err != nil {
+		return err
+	}
+
+	s.once.Do(func() {})
+	s.frames = frames
+	return nil
+}
+
+// StackTrace returns the call stack captured when the error was built, or nil
+// if stack capture was not enabled.
+func (se *CloudError) StackTrace() []StackFrame {
+	return se.Stack.Frames()
+}
+
+// CaptureStackOption captures the full call stack of an error built by
+// NewCloudError.
+func CaptureStackOption() CloudErrorOption {
+	return func(se *CloudError) {
+		se.Stack = &Stack{}
+	}
+}
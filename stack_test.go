@@ -0,0 +1,128 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCloudError_StackTrace(t *testing.T) {
+	When("stack capture is not enabled", t,
+		Then("no stack should be recorded", func(t *testing.T) {
+			if got := NewCloudError(500, "boom"); got.Stack != nil || got.StackTrace() != nil {
+				t.Errorf("expected no stack but got %+v", got.StackTrace())
+			}
+		}),
+	)
+
+	When("the builder is asked to capture the stack", t,
+		Then("the stack should start at the error location", func(t *testing.T) {
+			pc, _, line, _ := runtime.Caller(0)
+			got := NewCloudErrorBuilder().SkipCaller(1).CaptureStack().Build(time.Now().UTC())
+
+			frames := got.StackTrace()
+			if len(frames) < 2 {
+				t.Fatalf("expected at least 2 frames but got %+v", frames)
+			}
+			if frames[0].Function != runtime.FuncForPC(pc).Name() || frames[0].Line != line+1 {
+				t.Errorf("expected the first frame to be this test but got %+v", frames[0])
+			}
+			if frames[0].Function != got.ErrorLocation.Method {
+				t.Errorf("expected the first frame to match the error location %s but got %s", got.ErrorLocation.Method, frames[0].Function)
+			}
+		}),
+	)
+
+	When("the stack is requested with an option", t,
+		Then("the first frame should be the caller of NewCloudError", func(t *testing.T) {
+			pc, _, _, _ := runtime.Caller(0)
+			got := NewCloudError(500, "boom", CaptureStackOption())
+
+			frames := got.StackTrace()
+			if len(frames) == 0 || frames[0].Function != runtime.FuncForPC(pc).Name() {
+				t.Errorf("expected the first frame to be this test but got %+v", frames)
+			}
+		}),
+	)
+
+	When("stack capture is enabled for the process", t,
+		Then("every error should record its stack", func(t *testing.T) {
+			SetCaptureStacks(true)
+			defer SetCaptureStacks(false)
+
+			if got := NewCloudError(404, "missing"); len(got.StackTrace()) == 0 {
+				t.Errorf("expected a stack to be captured")
+			}
+		}),
+	)
+}
+
+func TestStack_JSON(t *testing.T) {
+	When("a cloud error with a stack is marshalled", t,
+		Then("the symbolised frames should be emitted and survive a round trip", func(t *testing.T) {
+			ce := NewCloudError(500, "boom", CaptureStackOption())
+
+			byt, err := json.Marshal(ce)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := new(CloudError)
+			if err := json.Unmarshal(byt, got); err != nil {
+				t.Fatal(err)
+			}
+
+			want := ce.StackTrace()
+			frames := got.StackTrace()
+			if len(frames) != len(want) || frames[0] != want[0] {
+				t.Errorf("expected frames \n%+v\nbut got \n%+v\n", want, frames)
+			}
+		}),
+	)
+
+	When("a cloud error without a stack is marshalled", t,
+		Then("the stack field should be omitted", func(t *testing.T) {
+			byt, err := json.Marshal(NewCloudError(500, "boom"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(byt), `"stack"`) {
+				t.Errorf("expected no stack field but got %s", byt)
+			}
+		}),
+	)
+}
+
+func TestCloudError_Format(t *testing.T) {
+	ce := NewCloudError(500, "boom", CaptureStackOption())
+
+	When("formatted with %+v", t,
+		Then("the stack trace should follow the full error", func(t *testing.T) {
+			got := fmt.Sprintf("%+v", ce)
+			frame := ce.StackTrace()[0]
+			want := fmt.Sprintf("\n%s\n\t%s:%d", frame.Function, frame.File, frame.Line)
+			if !strings.HasPrefix(got, "{") || !strings.Contains(got, want) {
+				t.Errorf("expected %q to contain the error and %q", got, want)
+			}
+		}),
+		And("the stack trace should only be printed once", func(t *testing.T) {
+			got := fmt.Sprintf("%+v", ce)
+			if strings.Contains(got, `"stack"`) {
+				t.Errorf("expected the stack trace to be left out of the JSON but got %q", got)
+			}
+		}),
+	)
+
+	When("formatted with %v or %s", t,
+		Then("only the error should be printed", func(t *testing.T) {
+			for _, verb := range []string{"%v", "%s"} {
+				if got := fmt.Sprintf(verb, ce); got != ce.Error() {
+					t.Errorf("%s: expected %q but got %q", verb, ce.Error(), got)
+				}
+			}
+		}),
+	)
+}
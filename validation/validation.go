@@ -0,0 +1,87 @@
+// Package validation turns the errors of github.com/go-playground/validator
+// into field errors. Convert can be given to handler.WithConverter so that
+// handlers returning validation errors respond with a 400:
+//
+//	e.HTTPErrorHandler = handler.NewCustomHTTPErrorHandler(handler.WithConverter(validation.Convert))
+package validation
+
+import (
+	"context"
+	errs "errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/music-tribe/errors"
+)
+
+// FromValidator returns the field errors of a validator.ValidationErrors in
+// err. Fields are named by their namespace without the top-level struct, e.g.
+// "Address.City", so register a tag name func with the validator to name them
+// as in the JSON body.
+func FromValidator(err error) (errors.FieldErrors, bool) {
+	var ves validator.ValidationErrors
+	if !errs.As(err, &ves) {
+		return nil, false
+	}
+
+	fes := make(errors.FieldErrors, len(ves))
+	for i, fe := range ves {
+		fes[i] = errors.NewFieldError(field(fe), fe.Tag(), message(fe), fe.Value())
+	}
+
+	return fes, true
+}
+
+// Convert converts a validator.ValidationErrors into a 400 Bad Request with
+// its field errors.
+func Convert(ctx context.Context, err error) (*errors.CloudError, bool) {
+	fes, ok := FromValidator(err)
+	if !ok {
+		return nil, false
+	}
+
+	return errors.NewCloudErrorBuilder().
+		StatusCode(http.StatusBadRequest).
+		Error(err).
+		Message("request validation failed").
+		FieldErrors(fes...).
+		Context(ctx).
+		Build(time.Now().UTC()), true
+}
+
+func field(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.IndexByte(ns, '.'); i >= 0 {
+		return ns[i+1:]
+	}
+
+	return ns
+}
+
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be an email address"
+	case "oneof":
+		return "must be one of " + fe.Param()
+	case "min", "gte":
+		return "must be at least " + fe.Param()
+	case "max", "lte":
+		return "must be at most " + fe.Param()
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "lt":
+		return "must be less than " + fe.Param()
+	case "len":
+		return "must have length " + fe.Param()
+	}
+
+	if fe.Param() != "" {
+		return "failed the " + fe.Tag() + "=" + fe.Param() + " rule"
+	}
+	return "failed the " + fe.Tag() + " rule"
+}
@@ -0,0 +1,57 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/music-tribe/errors"
+)
+
+type address struct {
+	City string `validate:"required"`
+}
+
+type preset struct {
+	Name    string `validate:"required,max=5"`
+	Kind    string `validate:"oneof=amp cab"`
+	Address address
+}
+
+func TestFromValidator(t *testing.T) {
+	err := validator.New().Struct(preset{Name: "too long", Kind: "pedal"})
+
+	t.Run("when a struct fails validation each field should have an error", func(t *testing.T) {
+		got, ok := FromValidator(fmt.Errorf("bind: %w", err))
+		if !ok {
+			t.Fatal("want the error to be converted")
+		}
+
+		want := errors.FieldErrors{
+			{Field: "Name", Rule: "max", Message: "must be at most 5"},
+			{Field: "Kind", Rule: "oneof", Message: "must be one of amp cab"},
+			{Field: "Address.City", Rule: "required", Message: "is required"},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("want %+v but got %+v", want, got)
+		}
+	})
+
+	t.Run("when the error is not from the validator it should not be converted", func(t *testing.T) {
+		if _, ok := FromValidator(fmt.Errorf("boom")); ok {
+			t.Error("want the error not to be converted")
+		}
+	})
+
+	t.Run("when converted it should be a 400 with the field errors", func(t *testing.T) {
+		ce, ok := Convert(errors.WithCorrelationID(context.Background(), "corr-1"), err)
+		if !ok {
+			t.Fatal("want the error to be converted")
+		}
+		if ce.StatusCode != 400 || len(ce.FieldErrors) != 3 || ce.CorrelationID != "corr-1" {
+			t.Errorf("unexpected error %+v", ce)
+		}
+	})
+}